@@ -0,0 +1,440 @@
+package pqxd
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/miyamo2/pqxd/internal/partiql"
+)
+
+// DefaultAggregateRowLimit is the default maximum number of items read by an aggregated SELECT statement.
+const DefaultAggregateRowLimit = 100000
+
+// avgFractionDigits is the number of digits after the decimal point of AVG, beyond those of the aggregated numbers.
+const avgFractionDigits = 20
+
+// aggregatePlan is the aggregation of a SELECT statement evaluated on the client side.
+type aggregatePlan struct {
+	// keys are the grouping keys of GROUP BY. empty if the whole result is a group.
+	keys []*partiql.Path
+
+	// columns are the selected items in order.
+	columns []aggregateColumn
+}
+
+// aggregateColumn is a selected item of the aggregated statement.
+type aggregateColumn struct {
+	// name is the column name
+	name string
+
+	// function is the aggregate function in upper case. empty if the column is a grouping key.
+	function string
+
+	// path is the aggregated attribute. nil for COUNT(*) and the grouping keys.
+	path *partiql.Path
+
+	// key is the index of the grouping key. -1 if the column is an aggregate function.
+	key int
+}
+
+// applyAggregate sets the aggregation from the SELECT statement.
+//
+// It returns the edits to select the grouping keys and the aggregated attributes instead of the projection,
+// and to remove the GROUP BY clause, since DynamoDB supports neither.
+func (p *queryPlan) applyAggregate(stmt *partiql.SelectStatement) []partiql.Edit {
+	aggregate := &aggregatePlan{}
+	if stmt.GroupBy != nil {
+		aggregate.keys = stmt.GroupBy.Keys
+	}
+	var (
+		roots []string
+		seen  = make(map[string]struct{})
+	)
+	addRoot := func(path *partiql.Path) {
+		if _, ok := seen[path.Root]; !ok {
+			seen[path.Root] = struct{}{}
+			roots = append(roots, p.rootSourceOf(path))
+		}
+	}
+	for _, key := range aggregate.keys {
+		addRoot(key)
+	}
+
+	p.selectedList = make([]string, 0, len(stmt.Projection.Items))
+	for _, item := range stmt.Projection.Items {
+		column := aggregateColumn{name: item.Name(), key: -1}
+		switch v := item.Expr.(type) {
+		case *partiql.AggregateExpr:
+			column.function = v.Func
+			column.path = v.Arg
+			if v.Arg != nil {
+				addRoot(v.Arg)
+			}
+		case *partiql.Path:
+			for i, key := range aggregate.keys {
+				if key.String() == v.String() {
+					column.key = i
+					break
+				}
+			}
+		}
+		p.selectedList = append(p.selectedList, column.name)
+		aggregate.columns = append(aggregate.columns, column)
+	}
+	p.aggregate = aggregate
+
+	projection := "*"
+	if len(roots) > 0 {
+		projection = strings.Join(roots, ", ")
+	}
+	edits := []partiql.Edit{{Span: stmt.Projection.Span, Text: projection}}
+	if stmt.GroupBy != nil {
+		edits = append(edits, partiql.Edit{Span: stmt.GroupBy.Span})
+	}
+	return edits
+}
+
+// aggregate pages through all the results of the statement of the plan, and returns the rows of the aggregated groups.
+// LIMIT and OFFSET are applied to the groups.
+//
+// It returns ErrAggregateRowLimitExceeded once the items read exceed the limit set by WithAggregateRowLimit,
+// and ErrCapacityBudgetExceeded once the capacity budget of the context is spent.
+func (c *connection) aggregate(
+	ctx context.Context, plan *queryPlan, fetch fetchClosure, capacity *capacityRecorder,
+) (driver.Rows, error) {
+	groups := newAggregateGroups(plan.aggregate)
+	limit := c.setting.aggregateRowLimit
+	read := 0
+	var nextToken *string
+	for {
+		var items []map[string]types.AttributeValue
+		nt, err := fetch(ctx, nextToken, &items)
+		if err != nil {
+			return nil, err
+		}
+		read += len(items)
+		if limit > 0 && read > limit {
+			return nil, fmt.Errorf("%w: more than %d items are read", ErrAggregateRowLimitExceeded, limit)
+		}
+		for _, item := range items {
+			if err := groups.add(item); err != nil {
+				return nil, err
+			}
+		}
+		if nt == nil {
+			break
+		}
+		nextToken = nt
+	}
+
+	out, err := groups.result()
+	if err != nil {
+		return nil, err
+	}
+	if plan.limit != nil {
+		offset := min(plan.limit.Offset, int64(len(out)))
+		out = out[offset:]
+		out = out[:min(plan.limit.Count, int64(len(out)))]
+	}
+	rows := newRows(plan.selectedList, nil, nil, out)
+	rows.capacity = capacity
+	rows.numberMode = c.setting.numberMode
+	rows.timeEncoding = c.setting.timeEncoding
+	rows.attributeValues = attributeValuesFromContext(ctx)
+	rows.typeRegistry = c.setting.typeRegistry
+	return rows, nil
+}
+
+// aggregateGroups accumulates the items per group in order of first appearance.
+type aggregateGroups struct {
+	plan *aggregatePlan
+
+	// index is the index of the group per grouping key.
+	index map[string]int
+
+	// groups are the groups in order of first appearance.
+	groups []*aggregateGroup
+}
+
+// aggregateGroup is a group of items sharing the grouping keys.
+type aggregateGroup struct {
+	// keys are the values of the grouping keys. nil if missing.
+	keys []types.AttributeValue
+
+	// accumulators are the accumulators of the columns. nil for the grouping keys.
+	accumulators []accumulator
+}
+
+// newAggregateGroups returns a new aggregateGroups.
+// Without GROUP BY, the whole result is a group, so that the aggregate functions return a row even if no item matches.
+func newAggregateGroups(plan *aggregatePlan) *aggregateGroups {
+	g := &aggregateGroups{plan: plan, index: make(map[string]int)}
+	if len(plan.keys) == 0 {
+		g.newGroup("", nil)
+	}
+	return g
+}
+
+// newGroup appends a new group of the grouping keys.
+func (g *aggregateGroups) newGroup(key string, keys []types.AttributeValue) *aggregateGroup {
+	group := &aggregateGroup{keys: keys, accumulators: make([]accumulator, len(g.plan.columns))}
+	for i, column := range g.plan.columns {
+		if column.function != "" {
+			group.accumulators[i] = newAccumulator(column)
+		}
+	}
+	g.index[key] = len(g.groups)
+	g.groups = append(g.groups, group)
+	return group
+}
+
+// add adds the item to its group.
+func (g *aggregateGroups) add(item map[string]types.AttributeValue) error {
+	keys := make([]types.AttributeValue, len(g.plan.keys))
+	parts := make([]string, len(g.plan.keys))
+	for i, path := range g.plan.keys {
+		if av, ok := resolvePath(item, path); ok {
+			keys[i] = av
+		}
+		parts[i] = strconv.Quote(groupingKeyOf(keys[i]))
+	}
+	key := strings.Join(parts, ",")
+
+	var group *aggregateGroup
+	if i, ok := g.index[key]; ok {
+		group = g.groups[i]
+	} else {
+		group = g.newGroup(key, keys)
+	}
+	for i, column := range g.plan.columns {
+		if column.function == "" {
+			continue
+		}
+		var (
+			av types.AttributeValue
+			ok = true
+		)
+		if column.path != nil {
+			av, ok = resolvePath(item, column.path)
+		}
+		if err := group.accumulators[i].add(av, ok); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// result returns the aggregated groups as items keyed by the column names.
+func (g *aggregateGroups) result() ([]map[string]types.AttributeValue, error) {
+	out := make([]map[string]types.AttributeValue, 0, len(g.groups))
+	for _, group := range g.groups {
+		item := make(map[string]types.AttributeValue, len(g.plan.columns))
+		for i, column := range g.plan.columns {
+			if column.function == "" {
+				if column.key >= 0 && group.keys[column.key] != nil {
+					item[column.name] = group.keys[column.key]
+				}
+				continue
+			}
+			av, err := group.accumulators[i].result()
+			if err != nil {
+				return nil, err
+			}
+			item[column.name] = av
+		}
+		out = append(out, item)
+	}
+	return out, nil
+}
+
+// groupingKeyOf returns the string identifying the value of a grouping key.
+// Numbers are compared by value, and NULL and missing attributes are in the same group.
+func groupingKeyOf(av types.AttributeValue) string {
+	switch v := av.(type) {
+	case nil, *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberS:
+		return "S:" + v.Value
+	case *types.AttributeValueMemberN:
+		if r, _, err := numberToRat(v); err == nil {
+			return "N:" + r.RatString()
+		}
+	}
+	value, _ := NumberModeString.decode(av)
+	return attributeTypeName(av) + ":" + fmt.Sprint(value)
+}
+
+// accumulator accumulates the values of an aggregate function.
+type accumulator interface {
+	// add adds the value of the aggregated attribute. ok is false if missing.
+	add(av types.AttributeValue, ok bool) error
+
+	// result returns the aggregated value.
+	result() (types.AttributeValue, error)
+}
+
+// newAccumulator returns the accumulator of the aggregate function of the column.
+func newAccumulator(column aggregateColumn) accumulator {
+	switch column.function {
+	case "SUM", "AVG":
+		return &sumAccumulator{column: column, sum: new(big.Rat)}
+	case "MIN", "MAX":
+		return &extremumAccumulator{column: column}
+	}
+	return &countAccumulator{star: column.path == nil}
+}
+
+// countAccumulator accumulates COUNT. COUNT(attr) counts the items whose attribute is neither NULL nor missing.
+type countAccumulator struct {
+	star  bool
+	count int64
+}
+
+// add See: accumulator
+func (a *countAccumulator) add(av types.AttributeValue, ok bool) error {
+	if _, null := av.(*types.AttributeValueMemberNULL); a.star || (ok && !null) {
+		a.count++
+	}
+	return nil
+}
+
+// result See: accumulator
+func (a *countAccumulator) result() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberN{Value: strconv.FormatInt(a.count, 10)}, nil
+}
+
+// sumAccumulator accumulates SUM and AVG exactly. NULL and missing attributes are ignored.
+type sumAccumulator struct {
+	column aggregateColumn
+	sum    *big.Rat
+	count  int64
+
+	// scale is the largest number of digits after the decimal point of the numbers.
+	scale int32
+}
+
+// add See: accumulator
+func (a *sumAccumulator) add(av types.AttributeValue, ok bool) error {
+	if _, null := av.(*types.AttributeValueMemberNULL); !ok || null {
+		return nil
+	}
+	n, isNumber := av.(*types.AttributeValueMemberN)
+	if !isNumber {
+		return fmt.Errorf("pqxd: %s: %s attribute is not a number", a.column.name, attributeTypeName(av))
+	}
+	r, scale, err := numberToRat(n)
+	if err != nil {
+		return err
+	}
+	a.sum.Add(a.sum, r)
+	a.count++
+	a.scale = max(a.scale, scale)
+	return nil
+}
+
+// result See: accumulator
+func (a *sumAccumulator) result() (types.AttributeValue, error) {
+	if a.count == 0 {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	if a.column.function == "SUM" {
+		return &types.AttributeValueMemberN{Value: ratToNumber(a.sum, int(a.scale))}, nil
+	}
+	avg := new(big.Rat).Quo(a.sum, new(big.Rat).SetInt64(a.count))
+	return &types.AttributeValueMemberN{Value: ratToNumber(avg, int(a.scale)+avgFractionDigits)}, nil
+}
+
+// extremumAccumulator accumulates MIN and MAX of numbers, strings or binaries. NULL and missing attributes are ignored.
+type extremumAccumulator struct {
+	column aggregateColumn
+	value  types.AttributeValue
+}
+
+// add See: accumulator
+func (a *extremumAccumulator) add(av types.AttributeValue, ok bool) error {
+	if _, null := av.(*types.AttributeValueMemberNULL); !ok || null {
+		return nil
+	}
+	if a.value == nil {
+		switch av.(type) {
+		case *types.AttributeValueMemberN, *types.AttributeValueMemberS, *types.AttributeValueMemberB:
+			a.value = av
+			return nil
+		}
+		return fmt.Errorf("pqxd: %s: %s attribute is not comparable", a.column.name, attributeTypeName(av))
+	}
+	cmp, err := compareAttributeValues(av, a.value)
+	if err != nil {
+		return fmt.Errorf("pqxd: %s: %w", a.column.name, err)
+	}
+	if (a.column.function == "MIN" && cmp < 0) || (a.column.function == "MAX" && cmp > 0) {
+		a.value = av
+	}
+	return nil
+}
+
+// result See: accumulator
+func (a *extremumAccumulator) result() (types.AttributeValue, error) {
+	if a.value == nil {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	return a.value, nil
+}
+
+// compareAttributeValues compares two numbers, strings or binaries of the same type.
+func compareAttributeValues(x, y types.AttributeValue) (int, error) {
+	switch x := x.(type) {
+	case *types.AttributeValueMemberN:
+		if y, ok := y.(*types.AttributeValueMemberN); ok {
+			xr, _, err := numberToRat(x)
+			if err != nil {
+				return 0, err
+			}
+			yr, _, err := numberToRat(y)
+			if err != nil {
+				return 0, err
+			}
+			return xr.Cmp(yr), nil
+		}
+	case *types.AttributeValueMemberS:
+		if y, ok := y.(*types.AttributeValueMemberS); ok {
+			return strings.Compare(x.Value, y.Value), nil
+		}
+	case *types.AttributeValueMemberB:
+		if y, ok := y.(*types.AttributeValueMemberB); ok {
+			return bytes.Compare(x.Value, y.Value), nil
+		}
+	}
+	return 0, fmt.Errorf("cannot compare %s attribute with %s attribute", attributeTypeName(x), attributeTypeName(y))
+}
+
+// numberToRat converts the N attribute to *big.Rat, along with the number of digits after the decimal point.
+func numberToRat(n *types.AttributeValueMemberN) (*big.Rat, int32, error) {
+	d, err := ParseDecimal(n.Value)
+	if err != nil {
+		return nil, 0, err
+	}
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(max(d.scale, -d.scale))), nil)
+	if d.scale < 0 {
+		return new(big.Rat).SetInt(pow.Mul(pow, d.coefficient())), 0, nil
+	}
+	return new(big.Rat).SetFrac(d.coefficient(), pow), d.scale, nil
+}
+
+// ratToNumber formats the number rounded to the digits after the decimal point, without trailing zeros.
+func ratToNumber(r *big.Rat, digits int) string {
+	s := r.FloatString(digits)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(strings.TrimRight(s, "0"), ".")
+	}
+	if s == "-0" {
+		return "0"
+	}
+	return s
+}
@@ -0,0 +1,176 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func Test_Connection_QueryContext_with_aggregates(t *testing.T) {
+	order := func(status, amount string) map[string]types.AttributeValue {
+		item := map[string]types.AttributeValue{"status": &types.AttributeValueMemberS{Value: status}}
+		if amount != "" {
+			item["amount"] = &types.AttributeValueMemberN{Value: amount}
+		}
+		return item
+	}
+	pages := []*dynamodb.ExecuteStatementOutput{
+		{
+			Items:     []map[string]types.AttributeValue{order("PAID", "0.1"), order("SHIPPED", "10"), order("PAID", "")},
+			NextToken: aws.String("1"),
+		},
+		{
+			Items: []map[string]types.AttributeValue{order("PAID", "0.2"), order("SHIPPED", "5")},
+		},
+	}
+	type want struct {
+		limit   *int32
+		columns []string
+		rows    [][]any
+		err     string
+	}
+	type test struct {
+		query   string
+		options []ConnectorOption
+		pages   []*dynamodb.ExecuteStatementOutput
+		want    want
+	}
+	tests := map[string]test{
+		"group-by": {
+			query: `SELECT status, COUNT(*), COUNT(amount) AS n, SUM(amount) AS total, AVG(amount), MAX(amount) FROM "orders" WHERE pk = ? GROUP BY status`,
+			pages: pages,
+			want: want{
+				columns: []string{"status", "COUNT(*)", "n", "total", "AVG(amount)", "MAX(amount)"},
+				rows: [][]any{
+					{"PAID", "3", "2", "0.3", "0.15", "0.2"},
+					{"SHIPPED", "2", "2", "15", "7.5", "10"},
+				},
+			},
+		},
+		"group-by-with-limit": {
+			query: `SELECT status, MIN(amount) FROM "orders" WHERE pk = ? GROUP BY status LIMIT 1 OFFSET 1`,
+			pages: pages,
+			want: want{
+				columns: []string{"status", "MIN(amount)"},
+				rows:    [][]any{{"SHIPPED", "5"}},
+			},
+		},
+		"group-by-with-limit-and-page-size": {
+			query:   `SELECT status, COUNT(*) FROM "orders" WHERE pk = ? GROUP BY status LIMIT 1`,
+			options: []ConnectorOption{WithDefaultPageSize(10)},
+			pages:   pages,
+			want: want{
+				limit:   aws.Int32(10),
+				columns: []string{"status", "COUNT(*)"},
+				rows:    [][]any{{"PAID", "3"}},
+			},
+		},
+		"no-items-without-group-by": {
+			query: `SELECT COUNT(*), SUM(amount) FROM "orders" WHERE pk = ?`,
+			pages: []*dynamodb.ExecuteStatementOutput{{}},
+			want: want{
+				columns: []string{"COUNT(*)", "SUM(amount)"},
+				rows:    [][]any{{"0", nil}},
+			},
+		},
+		"sum-of-string": {
+			query: `SELECT SUM(status) FROM "orders" WHERE pk = ?`,
+			pages: pages,
+			want: want{
+				err: "pqxd: SUM(status): S attribute is not a number",
+			},
+		},
+		"row-limit-exceeded": {
+			query:   `SELECT COUNT(*) FROM "orders" WHERE pk = ?`,
+			options: []ConnectorOption{WithAggregateRowLimit(4)},
+			pages:   pages,
+			want: want{
+				err: "pqxd: aggregate row limit exceeded: more than 4 items are read",
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+
+				limitEqual := CreateMatcher[*dynamodb.ExecuteStatementInput](
+					"Limit", func(_ []any, actual *dynamodb.ExecuteStatementInput) bool {
+						return actual != nil && cmp.Equal(actual.Limit, tt.want.limit)
+					},
+				)
+				stub := WhenDouble(client.ExecuteStatement(AnyContext(), limitEqual()))
+				for _, page := range tt.pages {
+					stub = stub.ThenReturn(page, nil)
+				}
+
+				options := append(tt.options, WithDynamoDBClient(client), WithNumberMode(NumberModeString))
+				db := sql.OpenDB(NewConnector(aws.Config{}, options...))
+				defer db.Close()
+
+				rows, err := db.QueryContext(context.Background(), tt.query, "1")
+				if tt.want.err != "" {
+					if err == nil || err.Error() != tt.want.err {
+						t.Fatalf("QueryContext() error = %v, want %v", err, tt.want.err)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("QueryContext() unexpected error = %v", err)
+				}
+				defer rows.Close()
+
+				columns, err := rows.Columns()
+				if err != nil {
+					t.Fatalf("Columns() unexpected error = %v", err)
+				}
+				if diff := cmp.Diff(tt.want.columns, columns); diff != "" {
+					t.Errorf("Columns() mismatch (-want +got):\n%s", diff)
+				}
+				var got [][]any
+				for rows.Next() {
+					row := make([]any, len(columns))
+					dest := make([]any, len(columns))
+					for i := range row {
+						dest[i] = &row[i]
+					}
+					if err := rows.Scan(dest...); err != nil {
+						t.Fatalf("Scan() unexpected error = %v", err)
+					}
+					got = append(got, row)
+				}
+				if err := rows.Err(); err != nil {
+					t.Fatalf("Err() unexpected error = %v", err)
+				}
+				if diff := cmp.Diff(tt.want.rows, got); diff != "" {
+					t.Errorf("rows mismatch (-want +got):\n%s", diff)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_QueryContext_with_aggregates_within_tx(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	sut := newConnection(client)
+	if _, err := sut.BeginTx(context.Background(), driver.TxOptions{}); err != nil {
+		t.Fatalf("BeginTx() unexpected error = %v", err)
+	}
+	_, err := sut.QueryContext(
+		context.Background(), `SELECT COUNT(*) AS n FROM "orders" WHERE pk = ?`, []driver.NamedValue{{Ordinal: 1, Value: "1"}},
+	)
+	if !errors.Is(err, ErrNotSupportedWithinTx) {
+		t.Errorf("QueryContext() error = %v, want %v", err, ErrNotSupportedWithinTx)
+	}
+}
@@ -0,0 +1,280 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+var (
+	// ErrNilBatchExecuteStatementOutput occurs when BatchExecuteStatement API returns nil output
+	ErrNilBatchExecuteStatementOutput = errors.New("[pqxd] nil BatchExecuteStatementOutput received")
+
+	// ErrNotSupportedInBatch occurs when the statement can not be executed in a batch
+	ErrNotSupportedInBatch = errors.New("pqxd: not supported in batch")
+
+	// ErrNotPqxdConnection occurs when the driver connection given to Batch.Exec is not a pqxd connection
+	ErrNotPqxdConnection = errors.New("pqxd: not a pqxd connection")
+)
+
+// batchChunkSize is the maximum number of statements in a single BatchExecuteStatement API call.
+const batchChunkSize = 25
+
+// batchRetryPolicy is the RetryPolicy for statements failed with a retryable error.
+var batchRetryPolicy = RetryPolicy{
+	MaxAttempts: 5,
+	BaseDelay:   50 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// Batch is a set of INSERT/UPDATE/DELETE statements executed with BatchExecuteStatement API.
+//
+// Statements are sent in chunks of 25, and those failed with a retryable error
+// (e.g. ThrottlingError) are retried with exponential backoff.
+//
+// Example:
+//
+//	var batch pqxd.Batch
+//	batch.Add(`INSERT INTO "users" VALUE {'id': ?, 'name': ?}`, "1", "Alice")
+//	batch.Add(`DELETE FROM "users" WHERE id = ?`, "2")
+//
+//	conn, err := db.Conn(ctx)
+//	if err != nil {
+//		return err
+//	}
+//	defer conn.Close()
+//	err = conn.Raw(func(driverConn any) error {
+//		return batch.Exec(ctx, driverConn)
+//	})
+type Batch struct {
+	statements []batchStatement
+}
+
+// batchStatement is a statement in Batch
+type batchStatement struct {
+	query string
+	args  []any
+}
+
+// Add appends the statement to the batch.
+func (b *Batch) Add(query string, args ...any) {
+	b.statements = append(b.statements, batchStatement{query: query, args: args})
+}
+
+// Len returns the number of statements in the batch.
+func (b *Batch) Len() int {
+	return len(b.statements)
+}
+
+// Exec executes the statements in the batch on driverConn given by sql.Conn.Raw.
+//
+// If some statements failed, Exec returns *BatchError.
+func (b *Batch) Exec(ctx context.Context, driverConn any) error {
+	c, ok := driverConn.(*connection)
+	if !ok {
+		return ErrNotPqxdConnection
+	}
+	return c.execBatch(ctx, b.statements)
+}
+
+// BatchError occurs when some statements in Batch failed.
+type BatchError struct {
+	// Failures are the failed statements, in order of Index.
+	Failures []*BatchStatementError
+}
+
+// Error See: error
+func (e *BatchError) Error() string {
+	codes := make([]string, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		codes = append(codes, fmt.Sprintf("#%d: %s", f.Index, f.Code))
+	}
+	return fmt.Sprintf("pqxd: %d statements in batch failed (%s)", len(e.Failures), strings.Join(codes, ", "))
+}
+
+// Unwrap returns the failed statements.
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Failures))
+	for _, f := range e.Failures {
+		errs = append(errs, f)
+	}
+	return errs
+}
+
+// BatchStatementError is a failed statement in Batch.
+type BatchStatementError struct {
+	// Index is the index of the statement in Batch, starting at 0.
+	Index int
+
+	// Code is the error code returned by DynamoDB.
+	Code types.BatchStatementErrorCodeEnum
+
+	// Message is the error message returned by DynamoDB.
+	Message string
+
+	// Item is the item which caused the condition check to fail. nil if not returned.
+	Item map[string]types.AttributeValue
+}
+
+// Error See: error
+func (e *BatchStatementError) Error() string {
+	return fmt.Sprintf("pqxd: statement #%d in batch failed: %s: %s", e.Index, e.Code, e.Message)
+}
+
+// execBatch executes the statements with BatchExecuteStatement API.
+func (c *connection) execBatch(ctx context.Context, statements []batchStatement) error {
+	if c.closed.Load() {
+		return driver.ErrBadConn
+	}
+	if c.txOngoing.Load() {
+		return ErrNotSupportedWithinTx
+	}
+
+	requests := make([]types.BatchStatementRequest, 0, len(statements))
+	for i, s := range statements {
+		plan, err := newQueryPlan(s.query)
+		if err != nil {
+			return fmt.Errorf("statement #%d: %w", i, err)
+		}
+		if !plan.batchable() {
+			return fmt.Errorf("statement #%d: %w", i, ErrNotSupportedInBatch)
+		}
+		if err := c.checkScan(ctx, plan); err != nil {
+			return fmt.Errorf("statement #%d: %w", i, err)
+		}
+		args, err := plan.bindArgs(toNamedValueFromAny(s.args))
+		if err != nil {
+			return fmt.Errorf("statement #%d: %w", i, err)
+		}
+		for j := range args {
+			if err := c.CheckNamedValue(&args[j]); err != nil && !errors.Is(err, driver.ErrSkip) {
+				return fmt.Errorf("statement #%d: %w", i, err)
+			}
+		}
+		params, err := toPartiQLParameters(args)
+		if err != nil {
+			return fmt.Errorf("statement #%d: %w", i, err)
+		}
+		requests = append(
+			requests, types.BatchStatementRequest{
+				Statement:                           aws.String(plan.statement),
+				Parameters:                          params,
+				ReturnValuesOnConditionCheckFailure: returnValuesOnConditionCheckFailureFromContext(ctx),
+			},
+		)
+	}
+
+	capacity := c.newCapacityRecorder(ctx, true)
+	var failures []*BatchStatementError
+	for offset := 0; offset < len(requests); offset += batchChunkSize {
+		chunk := requests[offset:min(offset+batchChunkSize, len(requests))]
+		f, err := c.execBatchChunk(ctx, chunk, offset, capacity)
+		if err != nil {
+			return err
+		}
+		failures = append(failures, f...)
+	}
+	if len(failures) > 0 {
+		return &BatchError{Failures: failures}
+	}
+	return nil
+}
+
+// execBatchChunk executes a chunk of statements, and retries those failed with a retryable error.
+// offset is the index of the first statement of the chunk in Batch.
+func (c *connection) execBatchChunk(
+	ctx context.Context, requests []types.BatchStatementRequest, offset int, capacity *capacityRecorder,
+) ([]*BatchStatementError, error) {
+	indexes := make([]int, len(requests))
+	for i := range indexes {
+		indexes[i] = offset + i
+	}
+
+	var failures []*BatchStatementError
+	for attempt := 1; ; attempt++ {
+		output, err := c.client.BatchExecuteStatement(
+			ctx, &dynamodb.BatchExecuteStatementInput{
+				Statements:             requests,
+				ReturnConsumedCapacity: c.returnConsumedCapacity(ctx),
+			},
+		)
+		if err != nil {
+			return nil, err
+		}
+		if output == nil {
+			return nil, ErrNilBatchExecuteStatementOutput
+		}
+		capacity.record(output.ConsumedCapacity...)
+
+		var (
+			retryRequests []types.BatchStatementRequest
+			retryIndexes  []int
+		)
+		for i, resp := range output.Responses {
+			if resp.Error == nil {
+				continue
+			}
+			if attempt < batchRetryPolicy.MaxAttempts && isRetryableBatchError(resp.Error.Code) {
+				retryRequests = append(retryRequests, requests[i])
+				retryIndexes = append(retryIndexes, indexes[i])
+				continue
+			}
+			failures = append(
+				failures, &BatchStatementError{
+					Index:   indexes[i],
+					Code:    resp.Error.Code,
+					Message: aws.ToString(resp.Error.Message),
+					Item:    resp.Error.Item,
+				},
+			)
+		}
+		if len(retryRequests) == 0 {
+			slices.SortFunc(
+				failures, func(a, b *BatchStatementError) int {
+					return a.Index - b.Index
+				},
+			)
+			return failures, nil
+		}
+
+		if err := batchRetryPolicy.wait(ctx, attempt); err != nil {
+			return nil, err
+		}
+		requests, indexes = retryRequests, retryIndexes
+	}
+}
+
+// isRetryableBatchError reports whether the statement failed with the code can be retried.
+func isRetryableBatchError(code types.BatchStatementErrorCodeEnum) bool {
+	switch code {
+	case types.BatchStatementErrorCodeEnumProvisionedThroughputExceeded,
+		types.BatchStatementErrorCodeEnumRequestLimitExceeded,
+		types.BatchStatementErrorCodeEnumThrottlingError,
+		types.BatchStatementErrorCodeEnumInternalServerError,
+		types.BatchStatementErrorCodeEnumTransactionConflict:
+		return true
+	}
+	return false
+}
+
+// toNamedValueFromAny converts []any to []driver.NamedValue. sql.NamedArg is converted into a named argument.
+func toNamedValueFromAny(args []any) []driver.NamedValue {
+	namedValues := make([]driver.NamedValue, 0, len(args))
+	for i, arg := range args {
+		if named, ok := arg.(sql.NamedArg); ok {
+			namedValues = append(namedValues, driver.NamedValue{Ordinal: i + 1, Name: named.Name, Value: named.Value})
+			continue
+		}
+		namedValues = append(namedValues, driver.NamedValue{Ordinal: i + 1, Value: arg})
+	}
+	return namedValues
+}
@@ -0,0 +1,163 @@
+package pqxd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func Test_Batch_Exec(t *testing.T) {
+	type want struct {
+		err      error
+		failures []*BatchStatementError
+	}
+	type test struct {
+		statements     int
+		query          string
+		dynamoDBClient func(t *testing.T) DynamoDBClient
+		want           want
+	}
+
+	batchRetryPolicy.BaseDelay = time.Millisecond
+
+	responses := func(n int, errs map[int]types.BatchStatementErrorCodeEnum) *dynamodb.BatchExecuteStatementOutput {
+		output := &dynamodb.BatchExecuteStatementOutput{Responses: make([]types.BatchStatementResponse, n)}
+		for i, code := range errs {
+			output.Responses[i].Error = &types.BatchStatementError{Code: code, Message: aws.String(string(code))}
+		}
+		return output
+	}
+
+	tests := map[string]test{
+		"happy-path": {
+			statements: 30,
+			query:      `INSERT INTO "users" VALUE {'id': ?}`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.BatchExecuteStatement(AnyContext(), Any[*dynamodb.BatchExecuteStatementInput]())).
+					ThenReturn(responses(25, nil), nil).
+					ThenReturn(responses(5, nil), nil).
+					Verify(Times(2))
+				return client
+			},
+		},
+		"with-retry-and-failures": {
+			statements: 30,
+			query:      `DELETE FROM "users" WHERE id = ?`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.BatchExecuteStatement(AnyContext(), Any[*dynamodb.BatchExecuteStatementInput]())).
+					ThenReturn(
+						responses(
+							25, map[int]types.BatchStatementErrorCodeEnum{
+								3: types.BatchStatementErrorCodeEnumThrottlingError,
+								7: types.BatchStatementErrorCodeEnumConditionalCheckFailed,
+							},
+						), nil,
+					).
+					ThenReturn(responses(1, nil), nil).
+					ThenReturn(
+						responses(
+							5, map[int]types.BatchStatementErrorCodeEnum{
+								2: types.BatchStatementErrorCodeEnumDuplicateItem,
+							},
+						), nil,
+					).
+					Verify(Times(3))
+				return client
+			},
+			want: want{
+				failures: []*BatchStatementError{
+					{
+						Index:   7,
+						Code:    types.BatchStatementErrorCodeEnumConditionalCheckFailed,
+						Message: string(types.BatchStatementErrorCodeEnumConditionalCheckFailed),
+					},
+					{
+						Index:   27,
+						Code:    types.BatchStatementErrorCodeEnumDuplicateItem,
+						Message: string(types.BatchStatementErrorCodeEnumDuplicateItem),
+					},
+				},
+			},
+		},
+		"retry-exhausted": {
+			statements: 1,
+			query:      `INSERT INTO "users" VALUE {'id': ?}`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.BatchExecuteStatement(AnyContext(), Any[*dynamodb.BatchExecuteStatementInput]())).
+					ThenReturn(
+						responses(
+							1, map[int]types.BatchStatementErrorCodeEnum{
+								0: types.BatchStatementErrorCodeEnumProvisionedThroughputExceeded,
+							},
+						), nil,
+					).
+					Verify(Times(batchRetryPolicy.MaxAttempts))
+				return client
+			},
+			want: want{
+				failures: []*BatchStatementError{
+					{
+						Index:   0,
+						Code:    types.BatchStatementErrorCodeEnumProvisionedThroughputExceeded,
+						Message: string(types.BatchStatementErrorCodeEnumProvisionedThroughputExceeded),
+					},
+				},
+			},
+		},
+		"not-batchable": {
+			statements: 1,
+			query:      `SELECT * FROM "users" WHERE id = ?`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.BatchExecuteStatement(AnyContext(), Any[*dynamodb.BatchExecuteStatementInput]())).
+					Verify(Never())
+				return client
+			},
+			want: want{err: ErrNotSupportedInBatch},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				var batch Batch
+				for i := range tt.statements {
+					batch.Add(tt.query, fmt.Sprint(i))
+				}
+				err := batch.Exec(context.Background(), newConnection(tt.dynamoDBClient(t)))
+				if tt.want.err != nil {
+					if !errors.Is(err, tt.want.err) {
+						t.Errorf("Exec() error = %v, want %v", err, tt.want.err)
+					}
+					return
+				}
+				var failures []*BatchStatementError
+				var batchErr *BatchError
+				if errors.As(err, &batchErr) {
+					failures = batchErr.Failures
+				} else if err != nil {
+					t.Fatalf("Exec() unexpected error = %v", err)
+				}
+				if diff := cmp.Diff(tt.want.failures, failures, cmpopts.EquateEmpty()); diff != "" {
+					t.Errorf("Exec() failures mismatch (-want +got):\n%s", diff)
+				}
+			},
+		)
+	}
+}
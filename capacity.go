@@ -0,0 +1,238 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql/driver"
+	"maps"
+	"slices"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// ResultConsumedCapacity is an extension of driver.Result for reporting the consumed capacity.
+// driver.Result returned from the connection obtained by sql.Conn.Raw implements it.
+type ResultConsumedCapacity interface {
+	driver.Result
+
+	// ConsumedCapacity returns the capacity consumed by the statement.
+	// Within a transaction, it returns the capacity consumed by the whole transaction after commit.
+	ConsumedCapacity() []types.ConsumedCapacity
+}
+
+// RowsConsumedCapacity is an extension of driver.Rows for reporting the consumed capacity.
+// driver.Rows returned from the connection obtained by sql.Conn.Raw implements it.
+type RowsConsumedCapacity interface {
+	driver.Rows
+
+	// ConsumedCapacity returns the capacity consumed by the pages fetched so far.
+	// Within a transaction, it returns the capacity consumed by the whole transaction after commit.
+	ConsumedCapacity() []types.ConsumedCapacity
+}
+
+// CapacityCollector collects the capacity consumed by the statements executed with the context.
+//
+// Example:
+//
+//	var collector pqxd.CapacityCollector
+//	rows, err := db.QueryContext(pqxd.WithCapacityCollector(ctx, &collector), `SELECT * FROM "users"`)
+//	...
+//	log.Printf("consumed %f capacity units", collector.CapacityUnits())
+type CapacityCollector struct {
+	mu       sync.Mutex
+	consumed []types.ConsumedCapacity
+}
+
+// ConsumedCapacity returns the collected ConsumedCapacity.
+func (c *CapacityCollector) ConsumedCapacity() []types.ConsumedCapacity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return slices.Clone(c.consumed)
+}
+
+// CapacityUnits returns the total of the collected capacity units.
+func (c *CapacityCollector) CapacityUnits() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	var total float64
+	for _, v := range c.consumed {
+		total += aws.ToFloat64(v.CapacityUnits)
+	}
+	return total
+}
+
+// collect appends the ConsumedCapacity.
+func (c *CapacityCollector) collect(consumed ...types.ConsumedCapacity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.consumed = append(c.consumed, consumed...)
+}
+
+// TableCapacity is the cumulative capacity units consumed on a table.
+type TableCapacity struct {
+	// ReadCapacityUnits is the cumulative read capacity units.
+	ReadCapacityUnits float64
+
+	// WriteCapacityUnits is the cumulative write capacity units.
+	WriteCapacityUnits float64
+}
+
+// CapacityCounter counts the cumulative capacity units consumed on each table through the connector.
+//
+// Example:
+//
+//	counter := &pqxd.CapacityCounter{}
+//	db := sql.OpenDB(pqxd.NewConnector(awsConfig, pqxd.WithCapacityCounter(counter)))
+//	...
+//	for table, capacity := range counter.Tables() {
+//		log.Printf("%s: %f RCU, %f WCU", table, capacity.ReadCapacityUnits, capacity.WriteCapacityUnits)
+//	}
+type CapacityCounter struct {
+	mu     sync.Mutex
+	tables map[string]TableCapacity
+}
+
+// Tables returns the cumulative capacity units per table.
+func (c *CapacityCounter) Tables() map[string]TableCapacity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return maps.Clone(c.tables)
+}
+
+// Table returns the cumulative capacity units of the table.
+func (c *CapacityCounter) Table(name string) TableCapacity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.tables[name]
+}
+
+// count adds the ConsumedCapacity to the counters.
+// If read/write capacity units are not reported, CapacityUnits is counted as write if write is true, otherwise read.
+func (c *CapacityCounter) count(write bool, consumed ...types.ConsumedCapacity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.tables == nil {
+		c.tables = make(map[string]TableCapacity)
+	}
+	for _, v := range consumed {
+		name := aws.ToString(v.TableName)
+		table := c.tables[name]
+		switch {
+		case v.ReadCapacityUnits != nil || v.WriteCapacityUnits != nil:
+			table.ReadCapacityUnits += aws.ToFloat64(v.ReadCapacityUnits)
+			table.WriteCapacityUnits += aws.ToFloat64(v.WriteCapacityUnits)
+		case write:
+			table.WriteCapacityUnits += aws.ToFloat64(v.CapacityUnits)
+		default:
+			table.ReadCapacityUnits += aws.ToFloat64(v.CapacityUnits)
+		}
+		c.tables[name] = table
+	}
+}
+
+// capacityBudget is the limit of the capacity units consumed by the SELECT statements.
+type capacityBudget struct {
+	mu    sync.Mutex
+	limit float64
+	spent float64
+}
+
+// spend adds the ConsumedCapacity to the spent capacity units.
+func (b *capacityBudget) spend(consumed ...types.ConsumedCapacity) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for _, v := range consumed {
+		b.spent += aws.ToFloat64(v.CapacityUnits)
+	}
+}
+
+// exceeded reports whether the budget is spent.
+func (b *capacityBudget) exceeded() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.spent >= b.limit
+}
+
+// capacityRecorder records the capacity consumed by a statement or a transaction
+// into itself, the CapacityCollector of the context and the CapacityCounter of the connector.
+type capacityRecorder struct {
+	mu       sync.Mutex
+	consumed []types.ConsumedCapacity
+
+	// write if true, the statement is a write statement
+	write bool
+
+	// collector is the CapacityCollector of the context. nil if not specified.
+	collector *CapacityCollector
+
+	// counter is the CapacityCounter of the connector. nil if not specified.
+	counter *CapacityCounter
+
+	// budget is the capacity budget of the context. nil if not specified. only applies to read statements.
+	budget *capacityBudget
+}
+
+// record records the ConsumedCapacity.
+func (r *capacityRecorder) record(consumed ...types.ConsumedCapacity) {
+	if r == nil || len(consumed) == 0 {
+		return
+	}
+	r.mu.Lock()
+	r.consumed = append(r.consumed, consumed...)
+	r.mu.Unlock()
+	if r.collector != nil {
+		r.collector.collect(consumed...)
+	}
+	if r.counter != nil {
+		r.counter.count(r.write, consumed...)
+	}
+	if r.budget != nil {
+		r.budget.spend(consumed...)
+	}
+}
+
+// budgetExceeded reports whether the capacity budget is spent. false if no budget.
+func (r *capacityRecorder) budgetExceeded() bool {
+	return r != nil && r.budget != nil && r.budget.exceeded()
+}
+
+// consumedCapacity returns the recorded ConsumedCapacity.
+func (r *capacityRecorder) consumedCapacity() []types.ConsumedCapacity {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return slices.Clone(r.consumed)
+}
+
+// newCapacityRecorder returns a new capacityRecorder for the statement executed with the context.
+func (c *connection) newCapacityRecorder(ctx context.Context, write bool) *capacityRecorder {
+	r := &capacityRecorder{
+		write:     write,
+		collector: capacityCollectorFromContext(ctx),
+		counter:   c.setting.capacityCounter,
+	}
+	if !write {
+		r.budget = capacityBudgetFromContext(ctx)
+	}
+	return r
+}
+
+// returnConsumedCapacity returns ReturnConsumedCapacity of the statement executed with the context.
+//
+// TOTAL is returned when the capacity is collected, counted or budgeted,
+// unless INDEXES is set up with WithReturnConsumedCapacity, since they require the consumed capacity even with NONE.
+func (c *connection) returnConsumedCapacity(ctx context.Context) types.ReturnConsumedCapacity {
+	mode := c.setting.returnConsumedCapacity
+	if mode != "" && mode != types.ReturnConsumedCapacityNone {
+		return mode
+	}
+	if c.setting.capacityCounter != nil ||
+		capacityCollectorFromContext(ctx) != nil ||
+		capacityBudgetFromContext(ctx) != nil {
+		return types.ReturnConsumedCapacityTotal
+	}
+	return mode
+}
@@ -0,0 +1,216 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func Test_Connection_ConsumedCapacity(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	selectQuery := `SELECT id FROM "users"`
+	WhenDouble(
+		client.ExecuteStatement(
+			AnyContext(),
+			ExecuteStatementInputEqual(
+				&dynamodb.ExecuteStatementInput{
+					Statement:              aws.String(selectQuery),
+					ReturnConsumedCapacity: types.ReturnConsumedCapacityIndexes,
+				},
+			)(),
+		),
+	).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				NextToken: aws.String("1"),
+				ConsumedCapacity: &types.ConsumedCapacity{
+					TableName:     aws.String("users"),
+					CapacityUnits: aws.Float64(0.5),
+				},
+			}, nil,
+		).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				ConsumedCapacity: &types.ConsumedCapacity{
+					TableName:     aws.String("users"),
+					CapacityUnits: aws.Float64(1.5),
+				},
+			}, nil,
+		)
+
+	insertQuery := `INSERT INTO "users" VALUE {'id': '1'}`
+	WhenDouble(
+		client.ExecuteStatement(
+			AnyContext(),
+			ExecuteStatementInputEqual(
+				&dynamodb.ExecuteStatementInput{
+					Statement:              aws.String(insertQuery),
+					ReturnConsumedCapacity: types.ReturnConsumedCapacityIndexes,
+				},
+			)(),
+		),
+	).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				ConsumedCapacity: &types.ConsumedCapacity{
+					TableName:          aws.String("users"),
+					CapacityUnits:      aws.Float64(2),
+					WriteCapacityUnits: aws.Float64(2),
+				},
+			}, nil,
+		)
+
+	counter := &CapacityCounter{}
+	sut := newConnection(
+		client, WithReturnConsumedCapacity(types.ReturnConsumedCapacityIndexes), WithCapacityCounter(counter),
+	)
+	var collector CapacityCollector
+	ctx := WithCapacityCollector(context.Background(), &collector)
+
+	rows, err := sut.QueryContext(ctx, selectQuery, nil)
+	if err != nil {
+		t.Fatalf("QueryContext() unexpected error = %v", err)
+	}
+	defer rows.Close()
+	if _, err := GetAllResultSet(t, rows); err != nil {
+		t.Fatalf("GetAllResultSet() unexpected error = %v", err)
+	}
+	if got := len(rows.(RowsConsumedCapacity).ConsumedCapacity()); got != 2 {
+		t.Errorf("len(RowsConsumedCapacity.ConsumedCapacity()) = %d, want 2", got)
+	}
+
+	result, err := sut.ExecContext(ctx, insertQuery, nil)
+	if err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+	if got := len(result.(ResultConsumedCapacity).ConsumedCapacity()); got != 1 {
+		t.Errorf("len(ResultConsumedCapacity.ConsumedCapacity()) = %d, want 1", got)
+	}
+
+	if got := collector.CapacityUnits(); got != 4 {
+		t.Errorf("CapacityCollector.CapacityUnits() = %f, want 4", got)
+	}
+	want := map[string]TableCapacity{
+		"users": {ReadCapacityUnits: 2, WriteCapacityUnits: 2},
+	}
+	if diff := cmp.Diff(want, counter.Tables()); diff != "" {
+		t.Errorf("CapacityCounter.Tables() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func Test_Connection_QueryContext_with_CapacityBudget(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	query := `SELECT id FROM "users"`
+	WhenDouble(
+		client.ExecuteStatement(
+			AnyContext(),
+			ExecuteStatementInputEqual(
+				&dynamodb.ExecuteStatementInput{
+					Statement:              aws.String(query),
+					ReturnConsumedCapacity: types.ReturnConsumedCapacityTotal,
+				},
+			)(),
+		),
+	).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{
+					{"id": &types.AttributeValueMemberS{Value: "1"}},
+				},
+				NextToken: aws.String("1"),
+				ConsumedCapacity: &types.ConsumedCapacity{
+					TableName:     aws.String("users"),
+					CapacityUnits: aws.Float64(1.5),
+				},
+			}, nil,
+		).
+		Verify(Times(1))
+
+	sut := newConnection(client)
+	ctx := WithCapacityBudget(context.Background(), 1)
+
+	rows, err := sut.QueryContext(ctx, query, nil)
+	if err != nil {
+		t.Fatalf("QueryContext() unexpected error = %v", err)
+	}
+	defer rows.Close()
+	dest := make([]driver.Value, 1)
+	if err := rows.Next(dest); err != nil {
+		t.Fatalf("Next() unexpected error = %v", err)
+	}
+	if err := rows.(driver.RowsNextResultSet).NextResultSet(); !errors.Is(err, ErrCapacityBudgetExceeded) {
+		t.Errorf("NextResultSet() error = %v, want %v", err, ErrCapacityBudgetExceeded)
+	}
+	if got := rows.(RowsNextToken).NextToken(); got != "1" {
+		t.Errorf("RowsNextToken.NextToken() = %s, want 1", got)
+	}
+	// the budget is shared, so the next statement fails before calling the API.
+	if _, err := sut.QueryContext(ctx, query, nil); !errors.Is(err, ErrCapacityBudgetExceeded) {
+		t.Errorf("QueryContext() error = %v, want %v", err, ErrCapacityBudgetExceeded)
+	}
+}
+
+func Test_Connection_returnConsumedCapacity(t *testing.T) {
+	type test struct {
+		ctx     context.Context
+		options []ConnectorOption
+		want    types.ReturnConsumedCapacity
+	}
+
+	tests := map[string]test{
+		"default": {
+			ctx:  context.Background(),
+			want: "",
+		},
+		"none": {
+			ctx:     context.Background(),
+			options: []ConnectorOption{WithReturnConsumedCapacity(types.ReturnConsumedCapacityNone)},
+			want:    types.ReturnConsumedCapacityNone,
+		},
+		"default-with-collector": {
+			ctx:  WithCapacityCollector(context.Background(), &CapacityCollector{}),
+			want: types.ReturnConsumedCapacityTotal,
+		},
+		"none-with-budget": {
+			ctx:     WithCapacityBudget(context.Background(), 1),
+			options: []ConnectorOption{WithReturnConsumedCapacity(types.ReturnConsumedCapacityNone)},
+			want:    types.ReturnConsumedCapacityTotal,
+		},
+		"none-with-counter": {
+			ctx: context.Background(),
+			options: []ConnectorOption{
+				WithReturnConsumedCapacity(types.ReturnConsumedCapacityNone), WithCapacityCounter(&CapacityCounter{}),
+			},
+			want: types.ReturnConsumedCapacityTotal,
+		},
+		"indexes-with-budget": {
+			ctx:     WithCapacityBudget(context.Background(), 1),
+			options: []ConnectorOption{WithReturnConsumedCapacity(types.ReturnConsumedCapacityIndexes)},
+			want:    types.ReturnConsumedCapacityIndexes,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				sut := newConnection(client, tt.options...)
+				if got := sut.returnConsumedCapacity(tt.ctx); got != tt.want {
+					t.Errorf("returnConsumedCapacity() = %s, want %s", got, tt.want)
+				}
+			},
+		)
+	}
+}
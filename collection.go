@@ -0,0 +1,464 @@
+package pqxd
+
+import (
+	"bytes"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"slices"
+	"strconv"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// compatibility check
+var (
+	_ sql.Scanner   = (*StringSet)(nil)
+	_ driver.Valuer = StringSet(nil)
+	_ sql.Scanner   = (*NumberSet)(nil)
+	_ driver.Valuer = NumberSet(nil)
+	_ sql.Scanner   = (*BinarySet)(nil)
+	_ driver.Valuer = BinarySet(nil)
+	_ sql.Scanner   = (*List[any])(nil)
+	_ driver.Valuer = List[any](nil)
+	_ sql.Scanner   = (*Map[any])(nil)
+	_ driver.Valuer = Map[any](nil)
+	_ sql.Scanner   = (*Document)(nil)
+	_ driver.Valuer = Document(nil)
+
+	_ attributevalue.Marshaler   = StringSet(nil)
+	_ attributevalue.Unmarshaler = (*StringSet)(nil)
+	_ attributevalue.Marshaler   = NumberSet(nil)
+	_ attributevalue.Unmarshaler = (*NumberSet)(nil)
+	_ attributevalue.Marshaler   = BinarySet(nil)
+	_ attributevalue.Unmarshaler = (*BinarySet)(nil)
+	_ attributevalue.Marshaler   = List[any](nil)
+	_ attributevalue.Unmarshaler = (*List[any])(nil)
+	_ attributevalue.Marshaler   = Map[any](nil)
+	_ attributevalue.Unmarshaler = (*Map[any])(nil)
+	_ attributevalue.Marshaler   = Document(nil)
+	_ attributevalue.Unmarshaler = (*Document)(nil)
+)
+
+// attributeValuer is implemented by the types that are sent as a specific attribute type of DynamoDB.
+type attributeValuer interface {
+	// attributeValue returns the attribute value to be sent.
+	attributeValue() (types.AttributeValue, error)
+}
+
+// StringSet is SS attribute of DynamoDB.
+//
+// Since DynamoDB does not allow empty sets, an empty StringSet is sent as NULL.
+type StringSet []string
+
+// attributeValue See: attributeValuer
+func (s StringSet) attributeValue() (types.AttributeValue, error) {
+	if len(s) == 0 {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	return &types.AttributeValueMemberSS{Value: slices.Clone(s)}, nil
+}
+
+// Value implements the driver.Valuer interface.
+func (s StringSet) Value() (driver.Value, error) {
+	return s.attributeValue()
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (s StringSet) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return s.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (s *StringSet) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, s)
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *StringSet) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+	case []string:
+		*s = slices.Clone(v)
+	default:
+		return fmt.Errorf("pqxd: converting %T to StringSet is unsupported", src)
+	}
+	return nil
+}
+
+// NumberSet is NS attribute of DynamoDB.
+// The elements are held as json.Number, which does not lose precision.
+//
+// Since DynamoDB does not allow empty sets, an empty NumberSet is sent as NULL.
+type NumberSet []json.Number
+
+// attributeValue See: attributeValuer
+func (s NumberSet) attributeValue() (types.AttributeValue, error) {
+	if len(s) == 0 {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	ns := &types.AttributeValueMemberNS{Value: make([]string, 0, len(s))}
+	for _, n := range s {
+		ns.Value = append(ns.Value, n.String())
+	}
+	return ns, nil
+}
+
+// Value implements the driver.Valuer interface.
+func (s NumberSet) Value() (driver.Value, error) {
+	return s.attributeValue()
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (s NumberSet) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return s.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (s *NumberSet) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, s)
+}
+
+// Scan implements the sql.Scanner interface.
+//
+// It accepts NS attributes returned in any NumberMode.
+func (s *NumberSet) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+	case []json.Number:
+		*s = slices.Clone(v)
+	case []float64:
+		*s = toNumberSet(v, func(f float64) string { return strconv.FormatFloat(f, 'g', -1, 64) })
+	case []string:
+		*s = toNumberSet(v, func(n string) string { return n })
+	case []Decimal:
+		*s = toNumberSet(v, Decimal.String)
+	default:
+		return fmt.Errorf("pqxd: converting %T to NumberSet is unsupported", src)
+	}
+	return nil
+}
+
+// toNumberSet converts the elements into json.Number with format.
+func toNumberSet[T any](s []T, format func(T) string) NumberSet {
+	set := make(NumberSet, 0, len(s))
+	for _, v := range s {
+		set = append(set, json.Number(format(v)))
+	}
+	return set
+}
+
+// BinarySet is BS attribute of DynamoDB.
+//
+// Since DynamoDB does not allow empty sets, an empty BinarySet is sent as NULL.
+type BinarySet [][]byte
+
+// attributeValue See: attributeValuer
+func (s BinarySet) attributeValue() (types.AttributeValue, error) {
+	if len(s) == 0 {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	return &types.AttributeValueMemberBS{Value: cloneBinaries(s)}, nil
+}
+
+// Value implements the driver.Valuer interface.
+func (s BinarySet) Value() (driver.Value, error) {
+	return s.attributeValue()
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (s BinarySet) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return s.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (s *BinarySet) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, s)
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *BinarySet) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*s = nil
+	case [][]byte:
+		*s = cloneBinaries(v)
+	default:
+		return fmt.Errorf("pqxd: converting %T to BinarySet is unsupported", src)
+	}
+	return nil
+}
+
+// cloneBinaries returns a deep copy of the binaries.
+func cloneBinaries(s [][]byte) [][]byte {
+	cloned := make([][]byte, 0, len(s))
+	for _, b := range s {
+		cloned = append(cloned, bytes.Clone(b))
+	}
+	return cloned
+}
+
+// List is L attribute of DynamoDB, whose elements are T.
+//
+// The elements are sent in the same way as parameters, so that StringSet, NumberSet and BinarySet are nested as sets,
+// and the other Go slices are nested as lists. A nil List is sent as NULL.
+type List[T any] []T
+
+// attributeValue See: attributeValuer
+func (l List[T]) attributeValue() (types.AttributeValue, error) {
+	if l == nil {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	return toListAttributeValue(l)
+}
+
+// Value implements the driver.Valuer interface.
+func (l List[T]) Value() (driver.Value, error) {
+	return l.attributeValue()
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (l List[T]) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return l.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (l *List[T]) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, l)
+}
+
+// Scan implements the sql.Scanner interface.
+//
+// The elements are unmarshalled into T with attributevalue.Unmarshal.
+func (l *List[T]) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*l = nil
+		return nil
+	case []T:
+		*l = slices.Clone(v)
+		return nil
+	}
+	av, err := driverValueToAttributeValue(src)
+	if err != nil {
+		return err
+	}
+	if _, ok := av.(*types.AttributeValueMemberL); !ok {
+		return fmt.Errorf("pqxd: converting %T to List is unsupported", src)
+	}
+	return attributevalue.Unmarshal(av, (*[]T)(l))
+}
+
+// Map is M attribute of DynamoDB, whose values are T.
+//
+// The values are sent in the same way as parameters. A nil Map is sent as NULL.
+type Map[T any] map[string]T
+
+// attributeValue See: attributeValuer
+func (m Map[T]) attributeValue() (types.AttributeValue, error) {
+	if m == nil {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	return toMapAttributeValue(m)
+}
+
+// Value implements the driver.Valuer interface.
+func (m Map[T]) Value() (driver.Value, error) {
+	return m.attributeValue()
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (m Map[T]) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return m.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (m *Map[T]) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, m)
+}
+
+// Scan implements the sql.Scanner interface.
+//
+// The values are unmarshalled into T with attributevalue.Unmarshal.
+func (m *Map[T]) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*m = nil
+		return nil
+	case map[string]T:
+		*m = maps.Clone(v)
+		return nil
+	}
+	av, err := driverValueToAttributeValue(src)
+	if err != nil {
+		return err
+	}
+	if _, ok := av.(*types.AttributeValueMemberM); !ok {
+		return fmt.Errorf("pqxd: converting %T to Map is unsupported", src)
+	}
+	return attributevalue.Unmarshal(av, (*map[string]T)(m))
+}
+
+// Document is M attribute of DynamoDB with arbitrary nested attributes, such as an item.
+//
+// The values are scanned as returned by driver.Rows.Next, following NumberMode,
+// except that the sets are scanned as StringSet, NumberSet and BinarySet so that they are sent back as sets.
+// The values are sent in the same way as parameters. A nil Document is sent as NULL.
+type Document map[string]any
+
+// attributeValue See: attributeValuer
+func (d Document) attributeValue() (types.AttributeValue, error) {
+	if d == nil {
+		return &types.AttributeValueMemberNULL{Value: true}, nil
+	}
+	return toMapAttributeValue(d)
+}
+
+// Value implements the driver.Valuer interface.
+func (d Document) Value() (driver.Value, error) {
+	return d.attributeValue()
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (d Document) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return d.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (d *Document) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, d)
+}
+
+// Scan implements the sql.Scanner interface.
+func (d *Document) Scan(src any) error {
+	switch v := src.(type) {
+	case nil:
+		*d = nil
+	case map[string]any:
+		doc, err := toDocumentValue(v)
+		if err != nil {
+			return err
+		}
+		*d = doc.(map[string]any)
+	default:
+		return fmt.Errorf("pqxd: converting %T to Document is unsupported", src)
+	}
+	return nil
+}
+
+// toDocumentValue copies the value returned by driver.Rows.Next, replacing the sets with StringSet, NumberSet and BinarySet.
+func toDocumentValue(value any) (any, error) {
+	switch v := value.(type) {
+	case []string:
+		return StringSet(slices.Clone(v)), nil
+	case []float64, []json.Number, []Decimal:
+		var set NumberSet
+		if err := set.Scan(v); err != nil {
+			return nil, err
+		}
+		return set, nil
+	case [][]byte:
+		return BinarySet(cloneBinaries(v)), nil
+	case []any:
+		list := make([]any, 0, len(v))
+		for _, elem := range v {
+			converted, err := toDocumentValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			list = append(list, converted)
+		}
+		return list, nil
+	case map[string]any:
+		m := make(map[string]any, len(v))
+		for k, elem := range v {
+			converted, err := toDocumentValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			m[k] = converted
+		}
+		return m, nil
+	}
+	return value, nil
+}
+
+// driverValueToAttributeValue converts the value returned by driver.Rows.Next back into the attribute value.
+//
+// Unlike parameters, the Go slices of strings, numbers and binaries are converted into sets since they are decoded from sets.
+// With NumberModeString, N attributes are converted into S attributes.
+func driverValueToAttributeValue(value any) (types.AttributeValue, error) {
+	switch v := value.(type) {
+	case []string:
+		return StringSet(v).attributeValue()
+	case []float64, []json.Number, []Decimal:
+		var set NumberSet
+		if err := set.Scan(v); err != nil {
+			return nil, err
+		}
+		return set.attributeValue()
+	case [][]byte:
+		return BinarySet(v).attributeValue()
+	case []any:
+		av := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, 0, len(v))}
+		for _, elem := range v {
+			converted, err := driverValueToAttributeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			av.Value = append(av.Value, converted)
+		}
+		return av, nil
+	case map[string]any:
+		av := &types.AttributeValueMemberM{Value: make(map[string]types.AttributeValue, len(v))}
+		for k, elem := range v {
+			converted, err := driverValueToAttributeValue(elem)
+			if err != nil {
+				return nil, err
+			}
+			av.Value[k] = converted
+		}
+		return av, nil
+	}
+	return toAttributeValue(value)
+}
+
+// unmarshalAttributeValue decodes the attribute value as driver.Rows.Next with NumberModeJSONNumber, and scans it into dst.
+func unmarshalAttributeValue(av types.AttributeValue, dst sql.Scanner) error {
+	v, err := NumberModeJSONNumber.decode(av)
+	if err != nil {
+		return err
+	}
+	return dst.Scan(v)
+}
+
+// toListAttributeValue converts the slice into L attribute.
+func toListAttributeValue[T any](s []T) (types.AttributeValue, error) {
+	av := &types.AttributeValueMemberL{Value: make([]types.AttributeValue, 0, len(s))}
+	for _, v := range s {
+		elem, err := toAttributeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		av.Value = append(av.Value, elem)
+	}
+	return av, nil
+}
+
+// toMapAttributeValue converts the map into M attribute.
+func toMapAttributeValue[T any](m map[string]T) (types.AttributeValue, error) {
+	av := &types.AttributeValueMemberM{Value: make(map[string]types.AttributeValue, len(m))}
+	for k, v := range m {
+		elem, err := toAttributeValue(v)
+		if err != nil {
+			return nil, err
+		}
+		av.Value[k] = elem
+	}
+	return av, nil
+}
@@ -0,0 +1,200 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func Test_toAttributeValue_collections(t *testing.T) {
+	type test struct {
+		value driver.Valuer
+		want  types.AttributeValue
+	}
+	tests := map[string]test{
+		"string-set": {
+			value: StringSet{"a", "b"},
+			want:  &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+		},
+		"empty-string-set": {
+			value: StringSet{},
+			want:  &types.AttributeValueMemberNULL{Value: true},
+		},
+		"number-set": {
+			value: NumberSet{"1", "12345678901234567890123456789012345678"},
+			want:  &types.AttributeValueMemberNS{Value: []string{"1", "12345678901234567890123456789012345678"}},
+		},
+		"binary-set": {
+			value: BinarySet{[]byte("a")},
+			want:  &types.AttributeValueMemberBS{Value: [][]byte{[]byte("a")}},
+		},
+		"list-of-slices": {
+			value: List[[]string]{{"a"}},
+			want: &types.AttributeValueMemberL{
+				Value: []types.AttributeValue{
+					&types.AttributeValueMemberL{
+						Value: []types.AttributeValue{&types.AttributeValueMemberS{Value: "a"}},
+					},
+				},
+			},
+		},
+		"list-of-sets": {
+			value: List[StringSet]{{"a"}},
+			want: &types.AttributeValueMemberL{
+				Value: []types.AttributeValue{&types.AttributeValueMemberSS{Value: []string{"a"}}},
+			},
+		},
+		"map": {
+			value: Map[Decimal]{"n": must(ParseDecimal("0.1"))},
+			want: &types.AttributeValueMemberM{
+				Value: map[string]types.AttributeValue{"n": &types.AttributeValueMemberN{Value: "0.1"}},
+			},
+		},
+		"nil-map": {
+			value: Map[int](nil),
+			want:  &types.AttributeValueMemberNULL{Value: true},
+		},
+		"document": {
+			value: Document{"tags": NumberSet{"1"}, "nested": map[string]any{"b": [][]byte{[]byte("b")}}},
+			want: &types.AttributeValueMemberM{
+				Value: map[string]types.AttributeValue{
+					"tags": &types.AttributeValueMemberNS{Value: []string{"1"}},
+					"nested": &types.AttributeValueMemberM{
+						Value: map[string]types.AttributeValue{
+							"b": &types.AttributeValueMemberL{
+								Value: []types.AttributeValue{&types.AttributeValueMemberB{Value: []byte("b")}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				nv := driver.NamedValue{Ordinal: 1, Value: tt.value}
+				if err := newConnection(nil).CheckNamedValue(&nv); err != nil {
+					t.Fatalf("CheckNamedValue() unexpected error = %v", err)
+				}
+				got, err := toAttributeValue(nv.Value)
+				if err != nil {
+					t.Fatalf("toAttributeValue() unexpected error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("toAttributeValue() = %#v, want %#v", got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func Test_collections_Scan(t *testing.T) {
+	type test struct {
+		src  any
+		dest sql.Scanner
+		want any
+	}
+	tests := map[string]test{
+		"string-set": {
+			src:  []string{"a", "b"},
+			dest: new(StringSet),
+			want: &StringSet{"a", "b"},
+		},
+		"number-set-from-float64": {
+			src:  []float64{1, 2.5},
+			dest: new(NumberSet),
+			want: &NumberSet{"1", "2.5"},
+		},
+		"number-set-from-decimal": {
+			src:  []Decimal{must(ParseDecimal("0.1"))},
+			dest: new(NumberSet),
+			want: &NumberSet{"0.1"},
+		},
+		"binary-set": {
+			src:  [][]byte{[]byte("a")},
+			dest: new(BinarySet),
+			want: &BinarySet{[]byte("a")},
+		},
+		"list-of-int": {
+			src:  []any{float64(1), json.Number("2")},
+			dest: new(List[int]),
+			want: &List[int]{1, 2},
+		},
+		"list-of-any": {
+			src:  []any{"a", json.Number("1")},
+			dest: new(List[any]),
+			want: &List[any]{"a", json.Number("1")},
+		},
+		"map-of-decimal": {
+			src:  map[string]any{"n": json.Number("0.1")},
+			dest: new(Map[Decimal]),
+			want: &Map[Decimal]{"n": must(ParseDecimal("0.1"))},
+		},
+		"document": {
+			src:  map[string]any{"s": "a", "l": []any{true}},
+			dest: new(Document),
+			want: &Document{"s": "a", "l": []any{true}},
+		},
+		"null": {
+			src:  nil,
+			dest: &List[int]{1},
+			want: new(List[int]),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				if err := tt.dest.Scan(tt.src); err != nil {
+					t.Fatalf("Scan() unexpected error = %v", err)
+				}
+				if !reflect.DeepEqual(tt.dest, tt.want) {
+					t.Errorf("Scan() = %v, want %v", tt.dest, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func Test_DB_ExecContext_with_collections(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	input := &dynamodb.ExecuteStatementInput{
+		Statement: aws.String(`UPDATE "users" SET tags = ?, scores = ?, profile = ? WHERE id = ?`),
+		Parameters: []types.AttributeValue{
+			&types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+			&types.AttributeValueMemberL{
+				Value: []types.AttributeValue{
+					&types.AttributeValueMemberN{Value: "1"},
+					&types.AttributeValueMemberN{Value: "2"},
+				},
+			},
+			&types.AttributeValueMemberM{
+				Value: map[string]types.AttributeValue{"bin": &types.AttributeValueMemberBS{Value: [][]byte{[]byte("b")}}},
+			},
+			&types.AttributeValueMemberS{Value: "1"},
+		},
+	}
+	WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+		ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil).
+		Verify(Times(1))
+
+	db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client)))
+	defer db.Close()
+	_, err := db.ExecContext(
+		context.Background(), *input.Statement,
+		StringSet{"a", "b"}, List[int]{1, 2}, Document{"bin": BinarySet{[]byte("b")}}, "1",
+	)
+	if err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+}
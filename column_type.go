@@ -0,0 +1,235 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"sync"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// compatibility check
+var (
+	_ driver.RowsColumnTypeDatabaseTypeName = (*pqxdRows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*pqxdRows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*pqxdRows)(nil)
+	_ driver.RowsColumnTypeDatabaseTypeName = (*describeTableRows)(nil)
+	_ driver.RowsColumnTypeScanType         = (*describeTableRows)(nil)
+	_ driver.RowsColumnTypeNullable         = (*describeTableRows)(nil)
+)
+
+// scanTypes are the Go types of the values returned by driver.Rows.Next per attribute type.
+// N and NS depend on NumberMode.
+var scanTypes = map[string]reflect.Type{
+	"S":    reflect.TypeFor[string](),
+	"B":    reflect.TypeFor[[]byte](),
+	"BOOL": reflect.TypeFor[bool](),
+	"M":    reflect.TypeFor[map[string]any](),
+	"L":    reflect.TypeFor[[]any](),
+	"SS":   reflect.TypeFor[[]string](),
+	"BS":   reflect.TypeFor[[][]byte](),
+}
+
+// nullableScanTypes are the Go types for scanning the nullable columns per attribute type.
+// The types not listed here can hold nil as they are.
+var nullableScanTypes = map[string]reflect.Type{
+	"S":    reflect.TypeFor[sql.NullString](),
+	"BOOL": reflect.TypeFor[sql.NullBool](),
+}
+
+// attributeTypeName returns the name of the attribute type. e.g. S, N, BOOL
+func attributeTypeName(v types.AttributeValue) string {
+	switch v.(type) {
+	case *types.AttributeValueMemberS:
+		return "S"
+	case *types.AttributeValueMemberN:
+		return "N"
+	case *types.AttributeValueMemberB:
+		return "B"
+	case *types.AttributeValueMemberBOOL:
+		return "BOOL"
+	case *types.AttributeValueMemberNULL:
+		return "NULL"
+	case *types.AttributeValueMemberM:
+		return "M"
+	case *types.AttributeValueMemberL:
+		return "L"
+	case *types.AttributeValueMemberSS:
+		return "SS"
+	case *types.AttributeValueMemberNS:
+		return "NS"
+	case *types.AttributeValueMemberBS:
+		return "BS"
+	}
+	return ""
+}
+
+// columnSchema is the schema of the columns known from the target table.
+type columnSchema struct {
+	// attributeTypes are the types of the attributes in AttributeDefinitions.
+	attributeTypes map[string]string
+
+	// keys are the key attributes of the table and the index read, which every item has.
+	keys map[string]struct{}
+}
+
+// newColumnSchemaLoader returns a function that describes the target table of the plan once,
+// and returns its columnSchema. The function returns nil if the table is unknown.
+//
+// The table is described with ctx of the query, so that it follows the cancellation and the deadline of the query.
+func (c *connection) newColumnSchemaLoader(ctx context.Context, plan *queryPlan) func() *columnSchema {
+	ref, _, ok := plan.target()
+	if !ok {
+		return nil
+	}
+	return sync.OnceValue(
+		func() *columnSchema {
+			table, err := c.setting.schemaCache.describe(ctx, c.client, ref.Name)
+			if err != nil || table == nil {
+				return nil
+			}
+			schema := &columnSchema{
+				attributeTypes: make(map[string]string, len(table.AttributeDefinitions)),
+				keys:           make(map[string]struct{}),
+			}
+			for _, v := range table.AttributeDefinitions {
+				schema.attributeTypes[aws.ToString(v.AttributeName)] = string(v.AttributeType)
+			}
+			for _, index := range []string{"", ref.Index} {
+				partitionKey, sortKey := keyAttributes(table, index)
+				for _, key := range []string{partitionKey, sortKey} {
+					if key != "" {
+						schema.keys[key] = struct{}{}
+					}
+				}
+			}
+			return schema
+		},
+	)
+}
+
+// columnType returns the attribute type name of the column and whether the column is nullable.
+//
+// The key attributes of the table follow AttributeDefinitions,
+// and the others are inferred from the attribute values in the current result set.
+// The type name is empty if unknown or mixed.
+func (r *pqxdRows) columnType(index int) (typeName string, nullable bool) {
+	name := r.columnNames[index]
+	if r.columnPaths != nil && r.columnPaths[index] != nil {
+		// the key attributes are only known by the top-level attributes.
+		name = ""
+		if path := r.columnPaths[index]; len(path.Steps) == 0 {
+			name = path.Root
+		}
+	}
+	if name != "" && r.columnSchema != nil {
+		if schema := r.columnSchema(); schema != nil {
+			if t, ok := schema.attributeTypes[name]; ok {
+				_, key := schema.keys[name]
+				return t, !key
+			}
+		}
+	}
+
+	out := *r.out.Load()
+	if len(out) == 0 {
+		return "", true
+	}
+	mixed := false
+	for _, item := range out {
+		v, ok := r.attribute(item, index)
+		if !ok {
+			nullable = true
+			continue
+		}
+		t := attributeTypeName(v)
+		if t == "NULL" {
+			nullable = true
+			continue
+		}
+		if typeName != "" && typeName != t {
+			mixed = true
+		}
+		typeName = t
+	}
+	switch {
+	case mixed:
+		return "", nullable
+	case typeName == "":
+		return "NULL", true
+	}
+	return typeName, nullable
+}
+
+// ColumnTypeDatabaseTypeName See: driver.RowsColumnTypeDatabaseTypeName
+//
+// It returns the attribute type of DynamoDB. e.g. S, N, BOOL, M, SS
+func (r *pqxdRows) ColumnTypeDatabaseTypeName(index int) string {
+	typeName, _ := r.columnType(index)
+	return typeName
+}
+
+// ColumnTypeScanType See: driver.RowsColumnTypeScanType
+func (r *pqxdRows) ColumnTypeScanType(index int) reflect.Type {
+	typeName, nullable := r.columnType(index)
+	switch typeName {
+	case "N":
+		return r.numberMode.scanType(nullable)
+	case "NS":
+		return r.numberMode.setScanType()
+	}
+	if t, ok := nullableScanTypes[typeName]; ok && nullable {
+		return t
+	}
+	if t, ok := scanTypes[typeName]; ok {
+		return t
+	}
+	return reflect.TypeFor[any]()
+}
+
+// ColumnTypeNullable See: driver.RowsColumnTypeNullable
+func (r *pqxdRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	_, nullable = r.columnType(index)
+	return nullable, true
+}
+
+// describeTableField returns the field of types.TableDescription for the column.
+func (r *describeTableRows) describeTableField(index int) (reflect.StructField, bool) {
+	return reflect.TypeFor[types.TableDescription]().FieldByName(r.columnNames[index])
+}
+
+// ColumnTypeDatabaseTypeName See: driver.RowsColumnTypeDatabaseTypeName
+//
+// It returns the Go type of the field of types.TableDescription. e.g. types.TableStatus
+func (r *describeTableRows) ColumnTypeDatabaseTypeName(index int) string {
+	field, ok := r.describeTableField(index)
+	if !ok {
+		return ""
+	}
+	return field.Type.String()
+}
+
+// ColumnTypeScanType See: driver.RowsColumnTypeScanType
+func (r *describeTableRows) ColumnTypeScanType(index int) reflect.Type {
+	field, ok := r.describeTableField(index)
+	if !ok {
+		return reflect.TypeFor[any]()
+	}
+	return field.Type
+}
+
+// ColumnTypeNullable See: driver.RowsColumnTypeNullable
+func (r *describeTableRows) ColumnTypeNullable(index int) (nullable, ok bool) {
+	field, ok := r.describeTableField(index)
+	if !ok {
+		return false, false
+	}
+	switch field.Type.Kind() {
+	case reflect.Pointer, reflect.Slice, reflect.Map:
+		return true, true
+	}
+	return false, true
+}
@@ -0,0 +1,112 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func Test_pqxdRows_ColumnType(t *testing.T) {
+	type want struct {
+		databaseTypeName string
+		scanType         reflect.Type
+		nullable         bool
+	}
+
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+		ThenReturn(
+			&dynamodb.DescribeTableOutput{
+				Table: &types.TableDescription{
+					TableName: aws.String("users"),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("id"), KeyType: types.KeyTypeHash},
+					},
+					AttributeDefinitions: []types.AttributeDefinition{
+						{AttributeName: aws.String("id"), AttributeType: types.ScalarAttributeTypeN},
+					},
+				},
+			}, nil,
+		)
+	WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{
+					{
+						"id":    &types.AttributeValueMemberN{Value: "1"},
+						"name":  &types.AttributeValueMemberS{Value: "Alice"},
+						"tags":  &types.AttributeValueMemberSS{Value: []string{"a"}},
+						"score": &types.AttributeValueMemberN{Value: "1"},
+					},
+					{
+						"id":    &types.AttributeValueMemberN{Value: "2"},
+						"name":  &types.AttributeValueMemberNULL{Value: true},
+						"tags":  &types.AttributeValueMemberSS{Value: []string{"b"}},
+						"score": &types.AttributeValueMemberS{Value: "high"},
+					},
+				},
+			}, nil,
+		)
+
+	sut := newConnection(client)
+	rows, err := sut.QueryContext(context.Background(), `SELECT id, name, tags, score, missing FROM "users"`, nil)
+	if err != nil {
+		t.Fatalf("QueryContext() unexpected error = %v", err)
+	}
+	defer rows.Close()
+
+	wants := []want{
+		{databaseTypeName: "N", scanType: reflect.TypeFor[float64](), nullable: false},
+		{databaseTypeName: "S", scanType: reflect.TypeFor[sql.NullString](), nullable: true},
+		{databaseTypeName: "SS", scanType: reflect.TypeFor[[]string](), nullable: false},
+		{databaseTypeName: "", scanType: reflect.TypeFor[any](), nullable: false},
+		{databaseTypeName: "NULL", scanType: reflect.TypeFor[any](), nullable: true},
+	}
+	for i, want := range wants {
+		if got := rows.(driver.RowsColumnTypeDatabaseTypeName).ColumnTypeDatabaseTypeName(i); got != want.databaseTypeName {
+			t.Errorf("ColumnTypeDatabaseTypeName(%d) = %q, want %q", i, got, want.databaseTypeName)
+		}
+		if got := rows.(driver.RowsColumnTypeScanType).ColumnTypeScanType(i); got != want.scanType {
+			t.Errorf("ColumnTypeScanType(%d) = %v, want %v", i, got, want.scanType)
+		}
+		if got, _ := rows.(driver.RowsColumnTypeNullable).ColumnTypeNullable(i); got != want.nullable {
+			t.Errorf("ColumnTypeNullable(%d) = %v, want %v", i, got, want.nullable)
+		}
+	}
+}
+
+func Test_Connection_newColumnSchemaLoader_with_canceled_context(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+		ThenAnswer(
+			func(args []any) (*dynamodb.DescribeTableOutput, error) {
+				if err := args[0].(context.Context).Err(); err != nil {
+					return nil, err
+				}
+				return &dynamodb.DescribeTableOutput{Table: usersTable}, nil
+			},
+		).
+		Verify(Times(1))
+
+	plan, err := newQueryPlan(`SELECT id FROM "users"`)
+	if err != nil {
+		t.Fatalf("newQueryPlan() unexpected error = %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	sut := newConnection(client)
+	if got := sut.newColumnSchemaLoader(ctx, plan)(); got != nil {
+		t.Errorf("newColumnSchemaLoader()() = %v, want nil", got)
+	}
+}
@@ -2,11 +2,15 @@ package pqxd
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
+	"encoding/json"
 	"errors"
-	"regexp"
-	"strings"
+	"fmt"
+	"math"
+	"time"
 
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
@@ -24,6 +28,7 @@ var (
 	_ driver.ConnPrepareContext = (*connection)(nil)
 	_ driver.ConnBeginTx        = (*connection)(nil)
 	_ driver.Pinger             = (*connection)(nil)
+	_ driver.NamedValueChecker  = (*connection)(nil)
 )
 
 // connection is an implementation of driver.Conn
@@ -31,6 +36,9 @@ type connection struct {
 	// client DynamoDB Client
 	client DynamoDBClient
 
+	// setting is the setting of the connector
+	setting *ConnectorSetting
+
 	// closed if true, the connection is closed
 	closed atomic.Bool
 
@@ -56,6 +64,38 @@ func (c *connection) Ping(ctx context.Context) error {
 	return err
 }
 
+// CheckNamedValue See: driver.NamedValueChecker
+//
+// The values of the types registered with WithTypeConverter are encoded into the attribute values,
+// and time.Time is encoded with the encoding set by WithTimeEncoding.
+// Decimal and json.Number are passed through as they are to be sent as N attribute,
+// and StringSet, NumberSet, BinarySet, List, Map, Document and types.AttributeValue as the attribute values.
+// The others are converted by the default converter of database/sql.
+func (c *connection) CheckNamedValue(nv *driver.NamedValue) error {
+	if av, ok, err := c.setting.typeRegistry.encode(nv.Value); ok {
+		if err != nil {
+			return err
+		}
+		nv.Value = av
+		return nil
+	}
+	switch v := nv.Value.(type) {
+	case time.Time:
+		nv.Value = c.setting.timeEncoding.encode(v)
+		return nil
+	case Decimal, json.Number, types.AttributeValue:
+		return nil
+	case attributeValuer:
+		av, err := v.attributeValue()
+		if err != nil {
+			return err
+		}
+		nv.Value = av
+		return nil
+	}
+	return driver.ErrSkip
+}
+
 // Prepare See: driver.Conn
 func (c *connection) Prepare(query string) (driver.Stmt, error) {
 	return c.PrepareContext(context.Background(), query)
@@ -67,10 +107,11 @@ func (c *connection) PrepareContext(ctx context.Context, query string) (driver.S
 		return nil, driver.ErrBadConn
 	}
 
-	stmt, err := c.preparedStatementFromQueryString(query)
+	plan, err := newQueryPlan(query)
 	if err != nil {
 		return nil, err
 	}
+	stmt := newStatement(plan, c.queryWithPlan, c.execWithPlan, c.newCloseCheckClosure())
 
 	select {
 	default:
@@ -103,62 +144,53 @@ func (c *connection) ExecContext(ctx context.Context, query string, args []drive
 	if c.closed.Load() {
 		return nil, driver.ErrBadConn
 	}
-
-	params, err := toPartiQLParameters(args)
+	plan, err := newQueryPlan(query)
 	if err != nil {
 		return nil, err
 	}
-
-	if c.txOngoing.Load() {
-		inout := &transactionInOut{
-			input: types.ParameterizedStatement{
-				Statement:  &query,
-				Parameters: params,
-			},
-		}
-		c.txStmtPub.Load().publish(inout)
-		return newLazyResult(c.newTxGetAffected(inout, c.txCommit.Load())), nil
-	}
-
-	input := dynamodb.ExecuteStatementInput{
-		Statement:  &query,
-		Parameters: params,
-	}
-	_, err = c.client.ExecuteStatement(ctx, &input)
-	if err != nil {
-		return nil, err
-	}
-	return newPqxdResult(1), nil
+	return c.execWithPlan(ctx, plan, args)
 }
 
 // QueryContext See: driver.QueryerContext
 func (c *connection) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
-	tq := tokenize(query)
-	if len(tq.selectedList) == 0 {
-		return nil, ErrInvalidSyntaxOfQuery
-	}
-	if tq.listTable {
-		return c.listTables(ctx)
-	}
-	if tq.describeTableTarget != "" {
-		target := strings.TrimSpace(strings.ReplaceAll(tq.describeTableTarget, `'`, ""))
-		return c.describeTable(ctx, target, tq.selectedList, args)
+	plan, err := newQueryPlan(query)
+	if err != nil {
+		return nil, err
 	}
-	return c.query(ctx, tq.queryString, tq.selectedList, args)
+	return c.queryWithPlan(ctx, plan, args)
 }
 
 // BeginTx See: driver.ConnBeginTx
-func (c *connection) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx, error) {
+//
+// Since DynamoDB transactions are serializable, only sql.LevelDefault and sql.LevelSerializable are supported.
+// If opts.ReadOnly is true, only SELECT statements are accepted and the transaction runs as a read transaction.
+func (c *connection) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
 	if c.closed.Load() {
 		return nil, driver.ErrBadConn
 	}
 	if c.txOngoing.Load() {
 		return nil, ErrTxDualBoot
 	}
+	switch sql.IsolationLevel(opts.Isolation) {
+	case sql.LevelDefault, sql.LevelSerializable:
+	default:
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedIsolationLevel, sql.IsolationLevel(opts.Isolation))
+	}
+
+	clientRequestToken := clientRequestTokenFromContext(ctx)
+	if clientRequestToken == "" {
+		clientRequestToken = newClientRequestToken()
+	}
 
 	txStmtCh := make(chan *transactionInOut)
 
-	c.txStmtPub = *atomic.NewPointer(&transactionStatementPublisher{ch: txStmtCh})
+	c.txStmtPub = *atomic.NewPointer(&transactionStatementPublisher{ch: txStmtCh, readOnly: opts.ReadOnly})
+
+	returnConsumedCapacity := c.returnConsumedCapacity(ctx)
+	if returnConsumedCapacity == "" {
+		returnConsumedCapacity = types.ReturnConsumedCapacityNone
+	}
+	capacity := c.newCapacityRecorder(ctx, false)
 
 	commitCtx, commitFunc := context.WithCancel(ctx)
 	receiveResultCtx, receiveResultFunc := context.WithCancel(ctx)
@@ -167,6 +199,7 @@ func (c *connection) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx
 			ctx:           commitCtx,
 			function:      commitFunc,
 			receiveResult: receiveResultCtx,
+			capacity:      capacity,
 		},
 	)
 
@@ -197,13 +230,22 @@ func (c *connection) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx
 				for _, inout := range inouts {
 					inputs = append(inputs, inout.input)
 				}
-				txResult, err := c.client.ExecuteTransaction(
+				txResult, err := c.executeTransaction(
 					ctx, &dynamodb.ExecuteTransactionInput{
 						TransactStatements:     inputs,
-						ReturnConsumedCapacity: types.ReturnConsumedCapacityNone,
+						ClientRequestToken:     &clientRequestToken,
+						ReturnConsumedCapacity: returnConsumedCapacity,
 					},
 				)
 				if err != nil {
+					var canceled *types.TransactionCanceledException
+					if errors.As(err, &canceled) {
+						txErr := newTransactionCanceledError(canceled)
+						for i, inout := range inouts {
+							inout.err = txErr.forStatement(i)
+						}
+						return
+					}
 					for _, inout := range inouts {
 						inout.err = err
 					}
@@ -212,6 +254,8 @@ func (c *connection) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx
 				for i, resp := range txResult.Responses {
 					inouts[i].output = resp.Item
 				}
+				capacity.write = c.txStmtPub.Load().publishedKind() == txStatementWrite
+				capacity.record(txResult.ConsumedCapacity...)
 				return
 			case <-rollBackCtx.Done():
 				return
@@ -223,13 +267,41 @@ func (c *connection) BeginTx(ctx context.Context, _ driver.TxOptions) (driver.Tx
 	return c, nil
 }
 
-// query executes a query with given query-string, selected-list and arguments.
-func (c *connection) query(
-	ctx context.Context, query string, selectedList []string, args []driver.NamedValue,
-) (driver.Rows, error) {
+// executeTransaction calls ExecuteTransaction API, and retries it according to the retry policy of the connector.
+// Since the input has ClientRequestToken, the retry does not apply the statements twice.
+func (c *connection) executeTransaction(
+	ctx context.Context, input *dynamodb.ExecuteTransactionInput,
+) (*dynamodb.ExecuteTransactionOutput, error) {
+	policy := c.setting.txRetryPolicy
+	for attempt := 1; ; attempt++ {
+		output, err := c.client.ExecuteTransaction(ctx, input)
+		if err == nil || attempt >= policy.MaxAttempts || !isRetryableTxError(err) {
+			return output, err
+		}
+		if err := policy.wait(ctx, attempt); err != nil {
+			return nil, err
+		}
+	}
+}
+
+// execWithPlan executes the statement of the plan with given arguments.
+func (c *connection) execWithPlan(ctx context.Context, plan *queryPlan, args []driver.NamedValue) (driver.Result, error) {
 	if c.closed.Load() {
 		return nil, driver.ErrBadConn
 	}
+	if plan.ddl != nil {
+		return c.execDDL(ctx, plan.ddl)
+	}
+	if plan.explain != nil {
+		return nil, ErrNotSupported
+	}
+	args, err := plan.bindArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if err := c.checkScan(ctx, plan); err != nil {
+		return nil, err
+	}
 
 	params, err := toPartiQLParameters(args)
 	if err != nil {
@@ -237,233 +309,216 @@ func (c *connection) query(
 	}
 
 	if c.txOngoing.Load() {
+		if plan.aggregate != nil {
+			// the results are fetched after commit, so they cannot be paged through.
+			return nil, ErrNotSupportedWithinTx
+		}
 		inout := &transactionInOut{
 			input: types.ParameterizedStatement{
-				Statement:  &query,
-				Parameters: params,
+				Statement:                           &plan.statement,
+				Parameters:                          params,
+				ReturnValuesOnConditionCheckFailure: returnValuesOnConditionCheckFailureFromContext(ctx),
 			},
 		}
-		fetch := c.newTxFetchClosure(inout)
-		c.txStmtPub.Load().publish(inout)
-		return newTxRows(selectedList, fetch, c.txCommit.Load()), nil
+		if err := c.txStmtPub.Load().publish(inout, plan.txStatementKind()); err != nil {
+			return nil, err
+		}
+		txCommit := c.txCommit.Load()
+		return newLazyResult(c.newTxGetAffected(inout, txCommit), txCommit.capacity), nil
 	}
 
 	input := dynamodb.ExecuteStatementInput{
-		Statement:  &query,
-		Parameters: params,
+		Statement:                           &plan.statement,
+		Parameters:                          params,
+		ReturnConsumedCapacity:              c.returnConsumedCapacity(ctx),
+		ReturnValuesOnConditionCheckFailure: returnValuesOnConditionCheckFailureFromContext(ctx),
 	}
-	fetch := c.newFetchClosure(input)
-
-	var items []map[string]types.AttributeValue
-	nt, err := fetch(ctx, nil, &items)
+	output, err := c.client.ExecuteStatement(ctx, &input)
 	if err != nil {
 		return nil, err
 	}
-
-	return newRows(selectedList, nt, fetch, items), nil
+	result := newPqxdResult(1)
+	result.capacity = c.newCapacityRecorder(ctx, plan.txStatementKind() == txStatementWrite)
+	if output != nil && output.ConsumedCapacity != nil {
+		result.capacity.record(*output.ConsumedCapacity)
+	}
+	return result, nil
 }
 
-// named capture keys
-const (
-	// namedCaptureKeySelectedList is the named capture key for selected list
-	namedCaptureKeySelectedList = "selected_list"
-
-	// namedCaptureKeySELECTTableName is the named capture key for table name
-	namedCaptureKeySELECTTableName = "table_name"
-
-	// namedCaptureKeyWHERECondition is the named capture key for WHERE condition
-	namedCaptureKeyWHERECondition = "where"
-
-	// namedCaptureKeyINSERTValue is the named capture key for INSERT value
-	namedCaptureKeyINSERTValue = "insert_value"
-
-	// namedCaptureKeyUpdateSet is the named capture key for UPDATE set
-	namedCaptureKeyINSERTClause = "insert_clause"
-
-	// namedCaptureKeyUpdateSet is the named capture key for UPDATE set
-	namedCaptureKeyUpdateSet = "update_set"
-
-	// namedCaptureKeyUPDATEClause is the named capture key for UPDATE clause
-	namedCaptureKeyUPDATEClause = "update_clause"
-
-	// namedCaptureKeyDELETEClause is the named capture key for DELETE clause
-	namedCaptureKeyDELETEClause = "delete_clause"
-
-	// namedCaptureKeyRETURNINGSelectedList is the named capture key for RETURNING selected list
-	namedCaptureKeyRETURNINGSelectedList = "returning_selected_list"
-)
-
-// regular expression strings
-const (
-	// reStrWHERECondition is the regular expression for WHERE condition
-	reStrWHERECondition = `(?:WHERE\s+)(?P<` + namedCaptureKeyWHERECondition + `>(.+))`
-
-	// reStrColumnList is the common pattern for column lists supporting both quoted and unquoted column names
-	// Matches: *, id, "id", id,name, "id","name", "id",name, id ,name, id , name, etc.
-	reStrColumnList = `\*|("[a-z0-9_\-\.]{1,255}"|[a-z0-9_\-\.]{1,255})(\s*,\s*("[a-z0-9_\-\.]{1,255}"|[a-z0-9_\-\.]{1,255}))*`
-
-	// reStrSelectedList is the regular expression for selected list
-	reStrSelectedList = `(?P<` + namedCaptureKeySelectedList + `>(` + reStrColumnList + `))`
-
-	// reStrSELECTTableName is the regular expression for table name
-	reStrSELECTTableName = `(?P<` + namedCaptureKeySELECTTableName + `>("[a-z0-9_\-\.]{3,255}"(\."[a-z0-9_\-\.]{3,255}")?))`
-
-	// reStrSELECTStatement is the regular expression for SELECT statement
-	reStrSELECTStatement = `(?i)^\s*(?:SELECT)\s+` + reStrSelectedList + `\s+(?:FROM)\s+` + reStrSELECTTableName + `(\s+` + reStrWHERECondition + `)?` + `\s*$`
-
-	// reStrRETURNINGClause is the regular expression for RETURNING clause
-	reStrRETURNINGClause = `(?i).*(?:RETURNING\s+(ALL OLD|MODIFIED OLD|ALL NEW|MODIFIED NEW)\s+)(?P<` + namedCaptureKeyRETURNINGSelectedList + `>(` + reStrColumnList + `))\s*$`
-
-	// reStrINSERTClause is the regular expression for INSERT clause
-	reStrINSERTClause = `(?P<` + namedCaptureKeyINSERTClause + `>INSERT)`
-
-	// reStrINSERTValue is the regular expression for INSERT value
-	reStrINSERTValue = `(?P<` + namedCaptureKeyINSERTValue + `>(\{.+\}))`
-
-	// reStrINSERTStatement is the regular expression for INSERT statement
-	reStrINSERTStatement = `(?i)^\s*` + reStrINSERTClause + `\s+(?:INTO)\s+("[a-z0-9_\-\.]{3,255}")\s+(?:VALUE)\s+` + reStrINSERTValue + `\s*$`
-
-	// reStrUPDATEClause is the regular expression for UPDATE clause
-	reStrUPDATEClause = `(?P<` + namedCaptureKeyUPDATEClause + `>UPDATE)`
-
-	// reStrListAppend is the regular expression for list_append
-	reStrListAppend = `(list_append\((.+),\s*(.+)\))`
-
-	// reStrListSetAdd is the regular expression for list_set_add
-	reStrStringSetAdd = `(set_add\((.+),\s*(.+)\))`
-
-	// reStrSet is the regular expression for set type
-	reStrSet = `(<<\s*(,?\s*(.+)\s*)*\s*>>)`
-
-	// reStrList is the regular expression for list type
-	reStrList = `(\[\s*(,?\s*(.+)\s*)*\s*\])`
-
-	// reStrMap is the regular expression for map type
-	reStrMap = `(\{\s*(,?(.+)\s*:\s*(.+))*\s*\})`
-
-	// reStrS is the regular expression for string type
-	reStrS = `"(.+)"`
-
-	// reStrN is the regular expression for number type
-	reStrN = `(\d+)`
-
-	// reStrCollectionWithIndex is the regular expression for collection with index
-	reStrCollectionWithIndex = `(([a-z0-9_\-\.]{3,255}(\.[a-z0-9_\-\.]{3,255})*\[(\d+|'(.+)')\]))`
-
-	// reStrUPDATESet is the regular expression for UPDATE set
-	reStrUPDATESet = `(?P<` + namedCaptureKeyUpdateSet + `>(((\s+SET\s+[a-z0-9_\-\.]{3,255}\s*=\s*(` +
-		reStrS + `|` + reStrN + `|` + reStrList + `|` + reStrSet + `|` + reStrMap + `|` + reStrListAppend + `|` + reStrStringSetAdd + `|` + `\?` +
-		`))|(\s+REMOVE\s+[a-z0-9_\-\.]{3,255}\s*=\s*` + reStrCollectionWithIndex + `))+))`
-
-	// reStrUPDATEStatement is the regular expression for UPDATE statement
-	reStrUPDATEStatement = `(?i)^\s*` + reStrUPDATEClause + `(?:\s+("[a-z0-9_\-\.]{3,255}"))` + reStrUPDATESet + `\s*` + reStrWHERECondition + `\s*$`
-
-	// reStrDELETEClause is the regular expression for DELETE clause
-	reStrDELETEClause = `(?P<` + namedCaptureKeyDELETEClause + `>DELETE)`
-
-	// reStrDELETEStatement is the regular expression for DELETE statement
-	reStrDELETEStatement = `(?i)^\s*` + reStrDELETEClause + `(?:\s+FROM\s+("[a-z0-9_\-\.]{3,255}")\s+)` + reStrWHERECondition + `\s*$`
-
-	// reStrDescribeTable is the regular expression for describe table
-	reStrDescribeTable = `(?i)^\s*(?:SELECT)\s+` + reStrSelectedList + `\s+(?:FROM\s+"!pqxd_describe_table"\s+)` + `(?:WHERE\s+table_name\s*=\s*)(?P<` + namedCaptureKeyWHERECondition + `>(\?|'([a-z0-9_\-\.]{3,255})'))\s*$`
-
-	// reStrListTable is the regular expression for describe table
-	reStrListTable = `(?i)^\s*(?:SELECT)\s+\*\s+(?:FROM\s+"!pqxd_list_tables")\s*$`
-)
+// queryWithPlan routes the plan to the meta-tables or DynamoDB, and returns the rows.
+func (c *connection) queryWithPlan(ctx context.Context, plan *queryPlan, args []driver.NamedValue) (driver.Rows, error) {
+	if len(plan.selectedList) == 0 {
+		return nil, ErrInvalidSyntaxOfQuery
+	}
+	if plan.listTable {
+		return c.listTables(ctx)
+	}
+	args, err := plan.bindArgs(args)
+	if err != nil {
+		return nil, err
+	}
+	if plan.describeTableTarget != "" {
+		return c.describeTable(ctx, plan.describeTableTarget, plan.selectedList, args)
+	}
+	if plan.explain != nil {
+		return c.explain(ctx, plan.explain)
+	}
+	return c.query(ctx, plan, args)
+}
 
-// regexps
-var (
-	// reSELECT is the regular expression for SELECT statement
-	reSELECT = regexp.MustCompile(reStrSELECTStatement)
+// query executes the statement of the plan with given arguments, and returns the rows.
+func (c *connection) query(ctx context.Context, plan *queryPlan, args []driver.NamedValue) (driver.Rows, error) {
+	if c.closed.Load() {
+		return nil, driver.ErrBadConn
+	}
+	if err := c.checkScan(ctx, plan); err != nil {
+		return nil, err
+	}
 
-	// reINSERT is the regular expression for INSERT statement
-	reINSERT = regexp.MustCompile(reStrINSERTStatement)
+	params, err := toPartiQLParameters(args)
+	if err != nil {
+		return nil, err
+	}
 
-	// reUPDATE is the regular expression for UPDATE statement
-	reUPDATE = regexp.MustCompile(reStrUPDATEStatement)
+	if c.txOngoing.Load() {
+		if plan.aggregate != nil {
+			// the results are fetched after commit, so they cannot be paged through.
+			return nil, ErrNotSupportedWithinTx
+		}
+		inout := &transactionInOut{
+			input: types.ParameterizedStatement{
+				Statement:                           &plan.statement,
+				Parameters:                          params,
+				ReturnValuesOnConditionCheckFailure: returnValuesOnConditionCheckFailureFromContext(ctx),
+			},
+		}
+		if err := c.txStmtPub.Load().publish(inout, plan.txStatementKind()); err != nil {
+			return nil, err
+		}
+		fetch := c.newTxFetchClosure(inout)
+		if plan.limit != nil {
+			fetch = newLimitFetchClosure(fetch, plan.limit.Count, plan.limit.Offset)
+		}
+		rows := newTxRows(plan.selectedList, fetch, c.txCommit.Load())
+		rows.columnPaths = plan.selectedPaths
+		rows.columnSchema = c.newColumnSchemaLoader(ctx, plan)
+		rows.numberMode = c.setting.numberMode
+		rows.timeEncoding = c.setting.timeEncoding
+		rows.attributeValues = attributeValuesFromContext(ctx)
+		rows.typeRegistry = c.setting.typeRegistry
+		return rows, nil
+	}
 
-	// reDELETE is the regular expression for DELETE statement
-	reDELETE = regexp.MustCompile(reStrDELETEStatement)
+	consistentRead, err := c.consistentRead(ctx, plan)
+	if err != nil {
+		return nil, err
+	}
+	input := dynamodb.ExecuteStatementInput{
+		Statement:                           &plan.statement,
+		Parameters:                          params,
+		ConsistentRead:                      consistentRead,
+		Limit:                               c.pageSize(ctx, plan),
+		ReturnConsumedCapacity:              c.returnConsumedCapacity(ctx),
+		ReturnValuesOnConditionCheckFailure: returnValuesOnConditionCheckFailureFromContext(ctx),
+	}
+	capacity := c.newCapacityRecorder(ctx, plan.txStatementKind() == txStatementWrite)
+	fetch := c.newFetchClosure(input, capacity)
+	if plan.aggregate != nil {
+		return c.aggregate(ctx, plan, fetch, capacity)
+	}
+	if plan.limit != nil {
+		fetch = newLimitFetchClosure(fetch, plan.limit.Count, plan.limit.Offset)
+	}
 
-	// reRETURNING is the regular expression for RETURNING clause
-	reRETURNING = regexp.MustCompile(reStrRETURNINGClause)
+	codec := c.setting.nextTokenCodec
+	startToken, err := codec.decode(plan.statement, nextTokenFromContext(ctx))
+	if err != nil {
+		return nil, err
+	}
 
-	// reDescribeTable is the regular expression for describe table
-	reDescribeTable = regexp.MustCompile(reStrDescribeTable)
+	var items []map[string]types.AttributeValue
+	nt, err := fetch(ctx, startToken, &items)
+	if err != nil {
+		return nil, err
+	}
 
-	// reListTable is the regular expression for list table
-	reListTable = regexp.MustCompile(reStrListTable)
-)
+	rows := newRows(plan.selectedList, nt, fetch, items)
+	rows.encodeNextToken = func(token *string) string {
+		return codec.encode(plan.statement, token)
+	}
+	rows.cursor = cursorFromContext(ctx)
+	rows.capacity = capacity
+	rows.columnPaths = plan.selectedPaths
+	rows.columnSchema = c.newColumnSchemaLoader(ctx, plan)
+	rows.numberMode = c.setting.numberMode
+	rows.timeEncoding = c.setting.timeEncoding
+	rows.attributeValues = attributeValuesFromContext(ctx)
+	rows.typeRegistry = c.setting.typeRegistry
+	rows.storeCursor()
+	return rows, nil
+}
 
-var (
-	// returnableStatementRegexps is the list of regular expressions for returnable statements
-	returnableStatementRegexps = []*regexp.Regexp{reSELECT, reUPDATE, reDELETE}
-)
+// consistentRead returns ConsistentRead of the statement. nil if eventually consistent.
+//
+// The default of the connector is downgraded to eventually consistent reads on a global secondary index,
+// but the one specified by the context is rejected.
+// An index is regarded as global unless the cached schema of the table lists it as a local secondary index.
+func (c *connection) consistentRead(ctx context.Context, plan *queryPlan) (*bool, error) {
+	enabled, specified := consistentReadFromContext(ctx)
+	if !specified {
+		enabled = c.setting.consistentRead
+	}
+	if !enabled {
+		return nil, nil
+	}
+	if name, index := plan.readIndex(); index != "" {
+		table, err := c.setting.schemaCache.describe(ctx, c.client, name)
+		if err != nil {
+			return nil, err
+		}
+		if !isLocalSecondaryIndex(table, index) {
+			if specified {
+				return nil, ErrConsistentReadOnIndex
+			}
+			return nil, nil
+		}
+	}
+	return aws.Bool(true), nil
+}
 
-// preparedStatementFromQueryString returns prepared statement from the query string
-func (c *connection) preparedStatementFromQueryString(query string) (stmt driver.Stmt, err error) {
-	for _, regx := range returnableStatementRegexps {
-		if match := regx.FindStringSubmatch(query); len(match) > 0 {
-			tq := tokenize(query)
-			stmt = newStatement(
-				tq.queryString,
-				tq.selectedList,
-				countPlaceHolders(match, regx),
-				c.query,
-				c.ExecContext,
-				c.newCloseCheckClosure(),
-			)
-			return
+// pageSize returns Limit of the statement. nil if not limited.
+//
+// With the LIMIT clause, the page size is reduced to the number of items needed to produce the rows,
+// so that the first page does not evaluate more items than necessary.
+// It is not reduced for aggregates, since LIMIT and OFFSET count the groups instead of the items.
+func (c *connection) pageSize(ctx context.Context, plan *queryPlan) *int32 {
+	size, ok := pageSizeFromContext(ctx)
+	if !ok {
+		size = c.setting.pageSize
+	}
+	// Count and Offset are compared without adding, since the sum overflows with a huge OFFSET.
+	if plan.limit != nil && plan.aggregate == nil && plan.limit.Count <= math.MaxInt32-plan.limit.Offset {
+		// DynamoDB requires Limit to be at least 1.
+		needed := max(plan.limit.Count+plan.limit.Offset, 1)
+		if size <= 0 || needed < int64(size) {
+			size = int32(needed)
 		}
 	}
-	if match := reINSERT.FindStringSubmatch(query); len(match) > 0 {
-		stmt = newStatement(
-			query,
-			nil,
-			countPlaceHolders(match, reINSERT),
-			c.query,
-			c.ExecContext,
-			c.newCloseCheckClosure(),
-		)
-		return
-	}
-	if match := reDescribeTable.FindStringSubmatch(query); len(match) > 0 {
-		tq := tokenize(query)
-		stmt = newStatement(
-			tq.queryString,
-			tq.selectedList,
-			countPlaceHolders(match, reDescribeTable),
-			func(ctx context.Context, _ string, _ []string, args []driver.NamedValue) (driver.Rows, error) {
-				return c.describeTable(ctx, tq.describeTableTarget, tq.selectedList, args)
-			},
-			c.ExecContext,
-			c.newCloseCheckClosure(),
-		)
-		return
-	}
-	if match := reListTable.FindStringSubmatch(query); len(match) > 0 {
-		stmt = newStatement(
-			query,
-			[]string{"*"},
-			0,
-			func(ctx context.Context, _ string, _ []string, _ []driver.NamedValue) (driver.Rows, error) {
-				return c.listTables(ctx)
-			},
-			c.ExecContext,
-			c.newCloseCheckClosure(),
-		)
-		return
+	if size <= 0 {
+		return nil
 	}
-	err = ErrInvalidPreparedStatement
-	return
+	return &size
 }
 
-// newFetchClosure returns fetchClosure
-func (c *connection) newFetchClosure(input dynamodb.ExecuteStatementInput) fetchClosure {
+// newFetchClosure returns fetchClosure. The capacity consumed by each page is recorded into capacity.
+func (c *connection) newFetchClosure(input dynamodb.ExecuteStatementInput, capacity *capacityRecorder) fetchClosure {
 	return func(ctx context.Context, nextToken *string, dest *[]map[string]types.AttributeValue) (*string, error) {
 		if c.closed.Load() {
 			return nil, driver.ErrBadConn
 		}
+		if capacity.budgetExceeded() {
+			return nil, ErrCapacityBudgetExceeded
+		}
 		input.NextToken = nextToken
 		output, err := c.client.ExecuteStatement(ctx, &input)
 		if err != nil {
@@ -472,6 +527,9 @@ func (c *connection) newFetchClosure(input dynamodb.ExecuteStatementInput) fetch
 		if output == nil {
 			return nil, ErrNilExecuteStatementOutput
 		}
+		if output.ConsumedCapacity != nil {
+			capacity.record(*output.ConsumedCapacity)
+		}
 		*dest = output.Items
 		return output.NextToken, nil
 	}
@@ -487,10 +545,16 @@ func (c *connection) newCloseCheckClosure() func() error {
 	}
 }
 
-// newConnection returns a new connection
-func newConnection(client DynamoDBClient) *connection {
+// newConnection returns a new connection with the given DynamoDB client and ConnectorOption.
+func newConnection(client DynamoDBClient, options ...ConnectorOption) *connection {
+	return newConnectionWithSetting(newConnectorSetting(append([]ConnectorOption{WithDynamoDBClient(client)}, options...)...))
+}
+
+// newConnectionWithSetting returns a new connection with the setting of the connector.
+func newConnectionWithSetting(setting *ConnectorSetting) *connection {
 	return &connection{
-		client:    client,
+		client:    setting.client,
+		setting:   setting,
 		closed:    *atomic.NewBool(false),
 		txOngoing: *atomic.NewBool(false),
 	}
@@ -528,105 +592,6 @@ func (c *connection) newTxGetAffected(inOut *transactionInOut, txCommit *txCommi
 	}
 }
 
-type tokenizedQuery struct {
-	queryString         string
-	selectedListString  string
-	selectedList        []string
-	tableName           string
-	whereCondition      string
-	placeHolders        int
-	describeTableTarget string
-	listTable           bool
-}
-
-// tokenize tokenizes the query string
-func tokenize(query string) (tq tokenizedQuery) {
-	tq.queryString = query
-	if match := reSELECT.FindStringSubmatch(query); len(match) > 0 {
-		tq.selectedList, _ = selectedListFromMatchString(match, reSELECT, namedCaptureKeySelectedList)
-		tq.tableName = extractTableNameFromMatchString(match)
-		idx := reSELECT.SubexpIndex(namedCaptureKeyWHERECondition)
-		if idx != -1 {
-			tq.whereCondition = match[idx]
-		}
-		tq.placeHolders = countPlaceHolders(match, reSELECT)
-		return
-	}
-	if match := reRETURNING.FindStringSubmatch(query); len(match) > 0 {
-		tq.selectedList, tq.selectedListString = selectedListFromMatchString(
-			match,
-			reRETURNING,
-			namedCaptureKeyRETURNINGSelectedList,
-		)
-		idx := reRETURNING.SubexpIndex(namedCaptureKeyRETURNINGSelectedList)
-		if idx == -1 {
-			tq = tokenizedQuery{}
-			return
-		}
-		tq.whereCondition = match[idx]
-		if tq.selectedListString != "*" {
-			tq.queryString = strings.Replace(tq.queryString, tq.selectedListString, "*", 1)
-		}
-		return
-	}
-	if match := reDescribeTable.FindStringSubmatch(query); len(match) > 0 {
-		tq.selectedList, _ = selectedListFromMatchString(match, reDescribeTable, namedCaptureKeySelectedList)
-		idx := reDescribeTable.SubexpIndex(namedCaptureKeyWHERECondition)
-		if idx == -1 {
-			tq = tokenizedQuery{}
-			return
-		}
-		tq.describeTableTarget = match[idx]
-		return
-	}
-	if match := reListTable.FindStringSubmatch(query); len(match) > 0 {
-		tq.selectedList = []string{"*"}
-		tq.listTable = true
-		return
-	}
-	return
-}
-
-// selectedListFromMatchString extracts selected list from the match string
-func selectedListFromMatchString(match []string, regex *regexp.Regexp, namedCaptureKey string) (
-	columns []string, rawSelectedList string,
-) {
-	index := regex.SubexpIndex(namedCaptureKey)
-	if index == -1 {
-		return
-	}
-	rawSelectedList = strings.TrimSpace(match[index])
-	for _, v := range strings.Split(rawSelectedList, ",") {
-		trimmedQuot := strings.ReplaceAll(v, `'`, "")
-		trimmedWQuot := strings.ReplaceAll(trimmedQuot, `"`, "")
-		columns = append(columns, strings.TrimSpace(trimmedWQuot))
-	}
-	return
-}
-
-// extractTableNameFromMatchString extracts table name from the match string
-func extractTableNameFromMatchString(match []string) string {
-	v := match[reSELECT.SubexpIndex(namedCaptureKeySELECTTableName)]
-	trimmedQuot := strings.ReplaceAll(v, `'`, "")
-	trimmedWQuot := strings.ReplaceAll(trimmedQuot, `"`, "")
-	return strings.TrimSpace(trimmedWQuot)
-}
-
-// countPlaceHolders counts the number of placeholders in the query
-func countPlaceHolders(match []string, regx *regexp.Regexp) int {
-	var count int
-	if i := regx.SubexpIndex(namedCaptureKeyWHERECondition); i != -1 {
-		count += strings.Count(match[i], "?")
-	}
-	if i := regx.SubexpIndex(namedCaptureKeyINSERTValue); i != -1 {
-		count += strings.Count(match[i], "?")
-	}
-	if i := regx.SubexpIndex(namedCaptureKeyUpdateSet); i != -1 {
-		count += strings.Count(match[i], "?")
-	}
-	return count
-}
-
 // toNamedValue converts []driver.Value to []driver.NamedValue
 func toNamedValue(args []driver.Value) []driver.NamedValue {
 	namedValues := make([]driver.NamedValue, 0, len(args))
@@ -651,6 +616,10 @@ func toPartiQLParameters(args []driver.NamedValue) (params []types.AttributeValu
 // toAttributeValue converts interface{} to types.AttributeValue
 func toAttributeValue(value interface{}) (types.AttributeValue, error) {
 	switch v := value.(type) {
+	case Decimal:
+		return &types.AttributeValueMemberN{Value: v.String()}, nil
+	case json.Number:
+		return &types.AttributeValueMemberN{Value: v.String()}, nil
 	case driver.Valuer:
 		dv, err := v.Value()
 		if err != nil {
@@ -679,6 +648,13 @@ func toAttributeValue(value interface{}) (types.AttributeValue, error) {
 		return &v, nil
 	case types.AttributeValueMemberSS:
 		return &v, nil
+	case []any:
+		return toListAttributeValue(v)
+	case [][]byte:
+		// encoded as L, not BS. BinarySet is for BS.
+		return toListAttributeValue(v)
+	case map[string]any:
+		return toMapAttributeValue(v)
 	default:
 		return attributevalue.Marshal(value)
 	}
@@ -2,14 +2,17 @@ package pqxd
 
 import (
 	"context"
+	"database/sql"
 	"database/sql/driver"
 	"errors"
 	"testing"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
 	"go.uber.org/atomic"
 	"go.uber.org/mock/gomock"
 
@@ -367,7 +370,7 @@ func Test_pqxdRows_QueryContext(t *testing.T) {
 	}
 }
 
-func Test_tokenize_with_double_quoted_columns(t *testing.T) {
+func Test_newQueryPlan_with_double_quoted_columns(t *testing.T) {
 	type test struct {
 		query       string
 		wantColumns []string
@@ -435,18 +438,21 @@ func Test_tokenize_with_double_quoted_columns(t *testing.T) {
 	for name, tt := range tests {
 		t.Run(
 			name, func(t *testing.T) {
-				tq := tokenize(tt.query)
+				plan, err := newQueryPlan(tt.query)
+				if err != nil {
+					t.Fatalf("newQueryPlan() unexpected error = %v", err)
+				}
 
 				// Check if we got the expected number of columns
-				if len(tq.selectedList) != len(tt.wantColumns) {
-					t.Errorf("tokenize() got %d columns, want %d", len(tq.selectedList), len(tt.wantColumns))
+				if len(plan.selectedList) != len(tt.wantColumns) {
+					t.Errorf("newQueryPlan() got %d columns, want %d", len(plan.selectedList), len(tt.wantColumns))
 					return
 				}
 
 				// Check each column matches
 				for i, wantCol := range tt.wantColumns {
-					if tq.selectedList[i] != wantCol {
-						t.Errorf("tokenize() column[%d] = %q, want %q", i, tq.selectedList[i], wantCol)
+					if plan.selectedList[i] != wantCol {
+						t.Errorf("newQueryPlan() column[%d] = %q, want %q", i, plan.selectedList[i], wantCol)
 					}
 				}
 			},
@@ -510,3 +516,986 @@ func Test_Connection_PrepareContext_with_double_quoted_columns(t *testing.T) {
 		)
 	}
 }
+
+func Test_newQueryPlan(t *testing.T) {
+	type want struct {
+		statement           string
+		numInput            int
+		describeTableTarget string
+		listTable           bool
+		err                 *SyntaxError
+	}
+	type test struct {
+		query string
+		want  want
+	}
+
+	tests := map[string]test{
+		"returning-column-list-is-replaced": {
+			query: `UPDATE "users" SET name = ? WHERE id = ? RETURNING ALL OLD id, name`,
+			want: want{
+				statement: `UPDATE "users" SET name = ? WHERE id = ? RETURNING ALL OLD *`,
+				numInput:  2,
+			},
+		},
+		"placeholder-in-string-literal": {
+			query: `SELECT id FROM "users" WHERE note = '?' AND id = ?`,
+			want: want{
+				statement: `SELECT id FROM "users" WHERE note = '?' AND id = ?`,
+				numInput:  1,
+			},
+		},
+		"function-in-set-clause": {
+			query: `UPDATE "users" SET tags = list_append(tags, ?) WHERE id = ? AND begins_with(sk, ?)`,
+			want: want{
+				statement: `UPDATE "users" SET tags = list_append(tags, ?) WHERE id = ? AND begins_with(sk, ?)`,
+				numInput:  3,
+			},
+		},
+		"comments-are-removed": {
+			query: "-- find user\nSELECT id FROM \"users\" WHERE id = ?",
+			want: want{
+				statement: `SELECT id FROM "users" WHERE id = ?`,
+				numInput:  1,
+			},
+		},
+		"limit-clause-is-removed": {
+			query: `SELECT id FROM "users" WHERE pk = ? ORDER BY sk DESC LIMIT 20 OFFSET 40;`,
+			want: want{
+				statement: `SELECT id FROM "users" WHERE pk = ? ORDER BY sk DESC`,
+				numInput:  1,
+			},
+		},
+		"named-parameters-are-replaced": {
+			query: `UPDATE "users" SET name = :name WHERE id = @id AND name <> :name RETURNING ALL OLD name`,
+			want: want{
+				statement: `UPDATE "users" SET name = ? WHERE id = ? AND name <> ? RETURNING ALL OLD *`,
+				numInput:  2,
+			},
+		},
+		"numbered-parameters-are-replaced": {
+			query: `SELECT id FROM "users" WHERE id IN [$3, $1] LIMIT 10`,
+			want: want{
+				statement: `SELECT id FROM "users" WHERE id IN [?, ?]`,
+				numInput:  3,
+			},
+		},
+		"nested-paths-and-aliases-are-replaced": {
+			query: `SELECT id AS user_id, address.city, "address"['zip'], tags[1] FROM "users" WHERE id = ?`,
+			want: want{
+				statement: `SELECT id, address, tags FROM "users" WHERE id = ?`,
+				numInput:  1,
+			},
+		},
+		"aggregates-are-replaced-with-attributes": {
+			query: `SELECT status, COUNT(*) AS n, SUM(detail.amount) FROM "orders" WHERE pk = ? GROUP BY status ORDER BY sk LIMIT 5`,
+			want: want{
+				statement: `SELECT status, detail FROM "orders" WHERE pk = ?  ORDER BY sk`,
+				numInput:  1,
+			},
+		},
+		"count-star-selects-all-attributes": {
+			query: `SELECT COUNT(*) FROM "orders" WHERE pk = ?`,
+			want: want{
+				statement: `SELECT * FROM "orders" WHERE pk = ?`,
+				numInput:  1,
+			},
+		},
+		"describe-table-with-literal": {
+			query: `SELECT TableStatus FROM "!pqxd_describe_table" WHERE table_name = 'users'`,
+			want: want{
+				statement:           `SELECT TableStatus FROM "!pqxd_describe_table" WHERE table_name = 'users'`,
+				describeTableTarget: "users",
+			},
+		},
+		"describe-table-with-placeholder": {
+			query: `SELECT * FROM "!pqxd_describe_table" WHERE table_name = ?`,
+			want: want{
+				statement:           `SELECT * FROM "!pqxd_describe_table" WHERE table_name = ?`,
+				numInput:            1,
+				describeTableTarget: "?",
+			},
+		},
+		"list-tables": {
+			query: `SELECT * FROM "!pqxd_list_tables"`,
+			want: want{
+				statement: `SELECT * FROM "!pqxd_list_tables"`,
+				listTable: true,
+			},
+		},
+		"syntax-error": {
+			query: "SELECT id\nFROM \"users\"\nWHERE id = = ?",
+			want: want{
+				err: &SyntaxError{Line: 3, Column: 12, Msg: "unexpected =, expected expression"},
+			},
+		},
+		"describe-table-without-table-name": {
+			query: `SELECT * FROM "!pqxd_describe_table"`,
+			want: want{
+				err: &SyntaxError{
+					Line:   1,
+					Column: 1,
+					Msg:    `"!pqxd_describe_table" requires "WHERE table_name = ?" or "WHERE table_name = '<table name>'"`,
+				},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				got, err := newQueryPlan(tt.query)
+				if tt.want.err != nil {
+					if !errors.Is(err, ErrInvalidSyntaxOfQuery) {
+						t.Errorf("newQueryPlan() error = %v, want %v", err, ErrInvalidSyntaxOfQuery)
+					}
+					var serr *SyntaxError
+					if !errors.As(err, &serr) {
+						t.Fatalf("newQueryPlan() error = %v, want *SyntaxError", err)
+					}
+					if diff := cmp.Diff(tt.want.err, serr); diff != "" {
+						t.Errorf("newQueryPlan() error mismatch (-want +got):\n%s", diff)
+					}
+					return
+				}
+				if err != nil {
+					t.Fatalf("newQueryPlan() unexpected error = %v", err)
+				}
+				if got.statement != tt.want.statement {
+					t.Errorf("newQueryPlan().statement = %q, want %q", got.statement, tt.want.statement)
+				}
+				if got.numInput != tt.want.numInput {
+					t.Errorf("newQueryPlan().numInput = %d, want %d", got.numInput, tt.want.numInput)
+				}
+				if got.describeTableTarget != tt.want.describeTableTarget {
+					t.Errorf(
+						"newQueryPlan().describeTableTarget = %q, want %q",
+						got.describeTableTarget, tt.want.describeTableTarget,
+					)
+				}
+				if got.listTable != tt.want.listTable {
+					t.Errorf("newQueryPlan().listTable = %v, want %v", got.listTable, tt.want.listTable)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_ExecContext_DDL(t *testing.T) {
+	type test struct {
+		query          string
+		dynamoDBClient func(t *testing.T) DynamoDBClient
+		want           error
+	}
+
+	cmpOpts := []cmp.Option{
+		cmpopts.IgnoreUnexported(
+			dynamodb.CreateTableInput{},
+			dynamodb.UpdateTableInput{},
+			dynamodb.DeleteTableInput{},
+			dynamodb.DescribeTableInput{},
+			types.KeySchemaElement{},
+			types.AttributeDefinition{},
+			types.ProvisionedThroughput{},
+			types.GlobalSecondaryIndexUpdate{},
+			types.CreateGlobalSecondaryIndexAction{},
+			types.DeleteGlobalSecondaryIndexAction{},
+			types.Projection{},
+		),
+	}
+
+	ddlWaitInterval = time.Millisecond
+
+	tests := map[string]test{
+		"create-table": {
+			query: `CREATE TABLE "users" (sk N RANGE, pk S HASH) WITH BILLING_MODE=PROVISIONED, READ_CAPACITY=5, WRITE_CAPACITY=1`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				input := &dynamodb.CreateTableInput{
+					TableName: aws.String("users"),
+					KeySchema: []types.KeySchemaElement{
+						{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+						{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+					},
+					AttributeDefinitions: []types.AttributeDefinition{
+						{AttributeName: aws.String("sk"), AttributeType: types.ScalarAttributeTypeN},
+						{AttributeName: aws.String("pk"), AttributeType: types.ScalarAttributeTypeS},
+					},
+					BillingMode: types.BillingModeProvisioned,
+					ProvisionedThroughput: &types.ProvisionedThroughput{
+						ReadCapacityUnits:  aws.Int64(5),
+						WriteCapacityUnits: aws.Int64(1),
+					},
+				}
+				WhenDouble(client.CreateTable(AnyContext(), InputEqual(input, cmpOpts...)())).
+					ThenReturn(&dynamodb.CreateTableOutput{}, nil).
+					Verify(Times(1))
+				return client
+			},
+		},
+		"create-table-with-wait": {
+			query: `CREATE TABLE "users" (pk S HASH) WAIT`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.CreateTable(AnyContext(), Any[*dynamodb.CreateTableInput]())).
+					ThenReturn(&dynamodb.CreateTableOutput{}, nil).
+					Verify(Times(1))
+				input := &dynamodb.DescribeTableInput{TableName: aws.String("users")}
+				WhenDouble(client.DescribeTable(AnyContext(), InputEqual(input, cmpOpts...)())).
+					ThenReturn(
+						&dynamodb.DescribeTableOutput{
+							Table: &types.TableDescription{TableStatus: types.TableStatusCreating},
+						}, nil,
+					).
+					ThenReturn(
+						&dynamodb.DescribeTableOutput{
+							Table: &types.TableDescription{TableStatus: types.TableStatusActive},
+						}, nil,
+					).
+					Verify(Times(2))
+				return client
+			},
+		},
+		"drop-table-with-wait": {
+			query: `DROP TABLE "users" WAIT`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				input := &dynamodb.DeleteTableInput{TableName: aws.String("users")}
+				WhenDouble(client.DeleteTable(AnyContext(), InputEqual(input, cmpOpts...)())).
+					ThenReturn(&dynamodb.DeleteTableOutput{}, nil).
+					Verify(Times(1))
+				WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+					ThenReturn(
+						&dynamodb.DescribeTableOutput{
+							Table: &types.TableDescription{TableStatus: types.TableStatusDeleting},
+						}, nil,
+					).
+					ThenReturn(nil, &types.ResourceNotFoundException{}).
+					Verify(Times(2))
+				return client
+			},
+		},
+		"alter-table-add-index": {
+			query: `ALTER TABLE "users" ADD INDEX "gsi" (gsi_pk S HASH, gsi_sk N RANGE) WITH PROJECTION=keys_only`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				input := &dynamodb.UpdateTableInput{
+					TableName: aws.String("users"),
+					AttributeDefinitions: []types.AttributeDefinition{
+						{AttributeName: aws.String("gsi_pk"), AttributeType: types.ScalarAttributeTypeS},
+						{AttributeName: aws.String("gsi_sk"), AttributeType: types.ScalarAttributeTypeN},
+					},
+					GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+						{
+							Create: &types.CreateGlobalSecondaryIndexAction{
+								IndexName: aws.String("gsi"),
+								KeySchema: []types.KeySchemaElement{
+									{AttributeName: aws.String("gsi_pk"), KeyType: types.KeyTypeHash},
+									{AttributeName: aws.String("gsi_sk"), KeyType: types.KeyTypeRange},
+								},
+								Projection: &types.Projection{ProjectionType: types.ProjectionTypeKeysOnly},
+							},
+						},
+					},
+				}
+				WhenDouble(client.UpdateTable(AnyContext(), InputEqual(input, cmpOpts...)())).
+					ThenReturn(&dynamodb.UpdateTableOutput{}, nil).
+					Verify(Times(1))
+				return client
+			},
+		},
+		"alter-table-drop-index": {
+			query: `ALTER TABLE "users" DROP INDEX "gsi"`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				input := &dynamodb.UpdateTableInput{
+					TableName: aws.String("users"),
+					GlobalSecondaryIndexUpdates: []types.GlobalSecondaryIndexUpdate{
+						{Delete: &types.DeleteGlobalSecondaryIndexAction{IndexName: aws.String("gsi")}},
+					},
+				}
+				WhenDouble(client.UpdateTable(AnyContext(), InputEqual(input, cmpOpts...)())).
+					ThenReturn(&dynamodb.UpdateTableOutput{}, nil).
+					Verify(Times(1))
+				return client
+			},
+		},
+		"provisioned-without-capacity": {
+			query: `CREATE TABLE "users" (pk S HASH) WITH BILLING_MODE=PROVISIONED`,
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.CreateTable(AnyContext(), Any[*dynamodb.CreateTableInput]())).Verify(Never())
+				return client
+			},
+			want: ErrInvalidSyntaxOfQuery,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				sut := newConnection(tt.dynamoDBClient(t))
+				_, err := sut.ExecContext(context.Background(), tt.query, nil)
+				if !errors.Is(err, tt.want) {
+					t.Errorf("ExecContext() error = %v, want %v", err, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_Transaction_canceled(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	oldItem := map[string]types.AttributeValue{
+		"id":      &types.AttributeValueMemberS{Value: "2"},
+		"version": &types.AttributeValueMemberN{Value: "3"},
+	}
+	input := &dynamodb.ExecuteTransactionInput{
+		TransactStatements: []types.ParameterizedStatement{
+			{
+				Statement:  aws.String(`INSERT INTO "users" VALUE {'id': ?}`),
+				Parameters: []types.AttributeValue{&types.AttributeValueMemberS{Value: "1"}},
+			},
+			{
+				Statement:                           aws.String(`UPDATE "users" SET version = 2 WHERE id = ? AND version = 1`),
+				Parameters:                          []types.AttributeValue{&types.AttributeValueMemberS{Value: "2"}},
+				ReturnValuesOnConditionCheckFailure: types.ReturnValuesOnConditionCheckFailureAllOld,
+			},
+		},
+		ClientRequestToken:     aws.String("token"),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityNone,
+	}
+	cmpOpts := []cmp.Option{
+		cmpopts.IgnoreUnexported(
+			dynamodb.ExecuteTransactionInput{},
+			types.ParameterizedStatement{},
+			types.AttributeValueMemberS{},
+		),
+	}
+	WhenDouble(client.ExecuteTransaction(AnyContext(), InputEqual(input, cmpOpts...)())).
+		ThenReturn(
+			nil, &types.TransactionCanceledException{
+				Message: aws.String("Transaction cancelled"),
+				CancellationReasons: []types.CancellationReason{
+					{Code: aws.String("None")},
+					{
+						Code:    aws.String("ConditionalCheckFailed"),
+						Message: aws.String("The conditional request failed"),
+						Item:    oldItem,
+					},
+				},
+			},
+		).
+		Verify(Times(1))
+
+	ctx := context.Background()
+	sut := newConnection(client)
+	if _, err := sut.BeginTx(WithClientRequestToken(ctx, "token"), driver.TxOptions{}); err != nil {
+		t.Fatalf("BeginTx() unexpected error = %v", err)
+	}
+	insertResult, err := sut.ExecContext(
+		ctx, `INSERT INTO "users" VALUE {'id': ?}`, []driver.NamedValue{{Ordinal: 1, Value: "1"}},
+	)
+	if err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+	updateResult, err := sut.ExecContext(
+		WithReturnValuesOnConditionCheckFailure(ctx),
+		`UPDATE "users" SET version = 2 WHERE id = ? AND version = 1`,
+		[]driver.NamedValue{{Ordinal: 1, Value: "2"}},
+	)
+	if err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+	if err := sut.Commit(); err != nil {
+		t.Fatalf("Commit() unexpected error = %v", err)
+	}
+
+	wantReasons := []CancellationReason{
+		{Code: "None"},
+		{Code: "ConditionalCheckFailed", Message: "The conditional request failed", Item: oldItem},
+	}
+	for i, result := range []driver.Result{insertResult, updateResult} {
+		_, err := result.RowsAffected()
+		var txErr *TransactionCanceledError
+		if !errors.As(err, &txErr) {
+			t.Fatalf("RowsAffected() error = %v, want *TransactionCanceledError", err)
+		}
+		if txErr.StatementIndex != i {
+			t.Errorf("StatementIndex = %d, want %d", txErr.StatementIndex, i)
+		}
+		if diff := cmp.Diff(
+			wantReasons, txErr.CancellationReasons, cmpopts.IgnoreUnexported(types.AttributeValueMemberS{}, types.AttributeValueMemberN{}),
+		); diff != "" {
+			t.Errorf("CancellationReasons mismatch (-want +got):\n%s", diff)
+		}
+		if reason, _ := txErr.Reason(); reason.Code != wantReasons[i].Code {
+			t.Errorf("Reason().Code = %s, want %s", reason.Code, wantReasons[i].Code)
+		}
+	}
+}
+
+func Test_Connection_BeginTx_with_TxOptions(t *testing.T) {
+	type test struct {
+		opts       driver.TxOptions
+		statements []string
+		wantBegin  error
+		want       error
+	}
+
+	tests := map[string]test{
+		"read-only": {
+			opts: driver.TxOptions{ReadOnly: true},
+			statements: []string{
+				`SELECT * FROM "users" WHERE id = '1'`,
+				`SELECT * FROM "users" WHERE id = '2'`,
+			},
+		},
+		"read-only-with-write": {
+			opts: driver.TxOptions{ReadOnly: true},
+			statements: []string{
+				`SELECT * FROM "users" WHERE id = '1'`,
+				`DELETE FROM "users" WHERE id = '1'`,
+			},
+			want: ErrWriteInReadOnlyTx,
+		},
+		"write-with-exists": {
+			opts: driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelSerializable)},
+			statements: []string{
+				`EXISTS(SELECT * FROM "users" WHERE id = '1')`,
+				`DELETE FROM "users" WHERE id = '2'`,
+			},
+		},
+		"mixed": {
+			statements: []string{
+				`INSERT INTO "users" VALUE {'id': '1'}`,
+				`SELECT * FROM "users" WHERE id = '2'`,
+			},
+			want: ErrMixedTxStatements,
+		},
+		"unsupported-isolation-level": {
+			opts:      driver.TxOptions{Isolation: driver.IsolationLevel(sql.LevelReadCommitted)},
+			wantBegin: ErrUnsupportedIsolationLevel,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				ctx := context.Background()
+				sut := newConnection(client)
+				_, err := sut.BeginTx(ctx, tt.opts)
+				if !errors.Is(err, tt.wantBegin) {
+					t.Fatalf("BeginTx() error = %v, want %v", err, tt.wantBegin)
+				}
+				if err != nil {
+					return
+				}
+				defer sut.Rollback()
+
+				var got error
+				for _, query := range tt.statements {
+					if _, err := sut.ExecContext(ctx, query, nil); err != nil {
+						got = err
+						break
+					}
+				}
+				if !errors.Is(got, tt.want) {
+					t.Errorf("ExecContext() error = %v, want %v", got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_Commit_with_retry(t *testing.T) {
+	type test struct {
+		dynamoDBClient func(t *testing.T) DynamoDBClient
+		want           error
+	}
+
+	policy := RetryPolicy{MaxAttempts: 3, BaseDelay: time.Millisecond, MaxDelay: time.Millisecond}
+	input := &dynamodb.ExecuteTransactionInput{
+		TransactStatements: []types.ParameterizedStatement{
+			{Statement: aws.String(`DELETE FROM "users" WHERE id = '1'`)},
+		},
+		ClientRequestToken:     aws.String("token"),
+		ReturnConsumedCapacity: types.ReturnConsumedCapacityNone,
+	}
+	cmpOpts := []cmp.Option{
+		cmpopts.IgnoreUnexported(dynamodb.ExecuteTransactionInput{}, types.ParameterizedStatement{}),
+	}
+	conflict := &types.TransactionCanceledException{
+		CancellationReasons: []types.CancellationReason{{Code: aws.String("TransactionConflict")}},
+	}
+
+	tests := map[string]test{
+		"retry-on-conflict": {
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.ExecuteTransaction(AnyContext(), InputEqual(input, cmpOpts...)())).
+					ThenReturn(nil, conflict).
+					ThenReturn(nil, &types.ProvisionedThroughputExceededException{}).
+					ThenReturn(&dynamodb.ExecuteTransactionOutput{}, nil).
+					Verify(Times(3))
+				return client
+			},
+		},
+		"retry-exhausted": {
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.ExecuteTransaction(AnyContext(), InputEqual(input, cmpOpts...)())).
+					ThenReturn(nil, conflict).
+					Verify(Times(3))
+				return client
+			},
+			want: &TransactionCanceledError{},
+		},
+		"condition-check-failed": {
+			dynamoDBClient: func(t *testing.T) DynamoDBClient {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.ExecuteTransaction(AnyContext(), InputEqual(input, cmpOpts...)())).
+					ThenReturn(
+						nil, &types.TransactionCanceledException{
+							CancellationReasons: []types.CancellationReason{{Code: aws.String("ConditionalCheckFailed")}},
+						},
+					).
+					Verify(Times(1))
+				return client
+			},
+			want: &TransactionCanceledError{},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctx := context.Background()
+				sut := newConnection(tt.dynamoDBClient(t), WithTxRetryPolicy(policy))
+				if _, err := sut.BeginTx(WithClientRequestToken(ctx, "token"), driver.TxOptions{}); err != nil {
+					t.Fatalf("BeginTx() unexpected error = %v", err)
+				}
+				result, err := sut.ExecContext(ctx, `DELETE FROM "users" WHERE id = '1'`, nil)
+				if err != nil {
+					t.Fatalf("ExecContext() unexpected error = %v", err)
+				}
+				if err := sut.Commit(); err != nil {
+					t.Fatalf("Commit() unexpected error = %v", err)
+				}
+				_, err = result.RowsAffected()
+				if tt.want == nil {
+					if err != nil {
+						t.Errorf("RowsAffected() unexpected error = %v", err)
+					}
+					return
+				}
+				var txErr *TransactionCanceledError
+				if !errors.As(err, &txErr) {
+					t.Errorf("RowsAffected() error = %v, want %T", err, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_QueryContext_with_ConsistentRead(t *testing.T) {
+	lsiTable := &types.TableDescription{
+		TableName: aws.String("users"),
+		LocalSecondaryIndexes: []types.LocalSecondaryIndexDescription{
+			{IndexName: aws.String("lsi")},
+		},
+	}
+	type test struct {
+		ctx     context.Context
+		options []ConnectorOption
+		table   *types.TableDescription
+		query   string
+		want    *bool
+		wantErr error
+	}
+
+	tests := map[string]test{
+		"default": {
+			ctx:   context.Background(),
+			query: `SELECT * FROM "users" WHERE id = '1'`,
+		},
+		"connector-default": {
+			ctx:     context.Background(),
+			options: []ConnectorOption{WithDefaultConsistentRead(true)},
+			query:   `SELECT * FROM "users" WHERE id = '1'`,
+			want:    aws.Bool(true),
+		},
+		"context-override": {
+			ctx:     WithConsistentRead(context.Background(), false),
+			options: []ConnectorOption{WithDefaultConsistentRead(true)},
+			query:   `SELECT * FROM "users" WHERE id = '1'`,
+		},
+		"context": {
+			ctx:   WithConsistentRead(context.Background(), true),
+			query: `SELECT * FROM "users" WHERE id = '1'`,
+			want:  aws.Bool(true),
+		},
+		"connector-default-downgraded-on-index": {
+			ctx:     context.Background(),
+			options: []ConnectorOption{WithDefaultConsistentRead(true)},
+			table:   usersTable,
+			query:   `SELECT * FROM "users"."gsi" WHERE gsi_pk = '1'`,
+		},
+		"context-rejected-on-index": {
+			ctx:     WithConsistentRead(context.Background(), true),
+			table:   usersTable,
+			query:   `SELECT * FROM "users"."gsi" WHERE gsi_pk = '1'`,
+			wantErr: ErrConsistentReadOnIndex,
+		},
+		"connector-default-on-local-index": {
+			ctx:     context.Background(),
+			options: []ConnectorOption{WithDefaultConsistentRead(true)},
+			table:   lsiTable,
+			query:   `SELECT * FROM "users"."lsi" WHERE pk = '1'`,
+			want:    aws.Bool(true),
+		},
+		"context-on-local-index": {
+			ctx:   WithConsistentRead(context.Background(), true),
+			table: lsiTable,
+			query: `SELECT * FROM "users"."lsi" WHERE pk = '1'`,
+			want:  aws.Bool(true),
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				input := &dynamodb.ExecuteStatementInput{Statement: aws.String(tt.query), ConsistentRead: tt.want}
+				WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil)
+				WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+					ThenReturn(&dynamodb.DescribeTableOutput{Table: tt.table}, nil)
+
+				sut := newConnection(client, tt.options...)
+				_, err := sut.QueryContext(tt.ctx, tt.query, nil)
+				if !errors.Is(err, tt.wantErr) {
+					t.Errorf("QueryContext() error = %v, want %v", err, tt.wantErr)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_QueryContext_with_PageSize(t *testing.T) {
+	type test struct {
+		ctx     context.Context
+		options []ConnectorOption
+		want    *int32
+	}
+
+	tests := map[string]test{
+		"default": {
+			ctx: context.Background(),
+		},
+		"connector-default": {
+			ctx:     context.Background(),
+			options: []ConnectorOption{WithDefaultPageSize(10)},
+			want:    aws.Int32(10),
+		},
+		"context-override": {
+			ctx:     WithPageSize(context.Background(), 2),
+			options: []ConnectorOption{WithDefaultPageSize(10)},
+			want:    aws.Int32(2),
+		},
+		"context-unlimited": {
+			ctx:     WithPageSize(context.Background(), 0),
+			options: []ConnectorOption{WithDefaultPageSize(10)},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				query := `SELECT id FROM "users"`
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				input := &dynamodb.ExecuteStatementInput{Statement: aws.String(query), Limit: tt.want}
+				WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+					ThenReturn(
+						&dynamodb.ExecuteStatementOutput{
+							Items:     []map[string]types.AttributeValue{{"id": &types.AttributeValueMemberS{Value: "1"}}},
+							NextToken: aws.String("1"),
+						}, nil,
+					).
+					ThenReturn(
+						&dynamodb.ExecuteStatementOutput{
+							Items: []map[string]types.AttributeValue{{"id": &types.AttributeValueMemberS{Value: "2"}}},
+						}, nil,
+					).
+					Verify(Times(2))
+
+				sut := newConnection(client, tt.options...)
+				rows, err := sut.QueryContext(tt.ctx, query, nil)
+				if err != nil {
+					t.Fatalf("QueryContext() unexpected error = %v", err)
+				}
+				defer rows.Close()
+				resultSets, _ := GetAllResultSet(t, rows)
+				if len(resultSets) != 2 {
+					t.Errorf("len(resultSets) = %d, want 2", len(resultSets))
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_QueryContext_with_Limit(t *testing.T) {
+	type want struct {
+		ids   []string
+		limit *int32
+		calls int
+	}
+	type test struct {
+		ctx   context.Context
+		query string
+		want  want
+	}
+
+	page := func(ids ...string) []map[string]types.AttributeValue {
+		items := make([]map[string]types.AttributeValue, 0, len(ids))
+		for _, id := range ids {
+			items = append(items, map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}})
+		}
+		return items
+	}
+
+	tests := map[string]test{
+		"limit-and-offset": {
+			ctx:   context.Background(),
+			query: `SELECT id FROM "users" LIMIT 4 OFFSET 2`,
+			want: want{
+				ids:   []string{"3", "4", "5", "6"},
+				limit: aws.Int32(6),
+				calls: 2,
+			},
+		},
+		"offset-skips-whole-page": {
+			ctx:   context.Background(),
+			query: `SELECT id FROM "users" LIMIT 1 OFFSET 3`,
+			want: want{
+				ids:   []string{"4"},
+				limit: aws.Int32(4),
+				calls: 2,
+			},
+		},
+		"smaller-page-size": {
+			ctx:   WithPageSize(context.Background(), 3),
+			query: `SELECT id FROM "users" LIMIT 5`,
+			want: want{
+				ids:   []string{"1", "2", "3", "4", "5"},
+				limit: aws.Int32(3),
+				calls: 2,
+			},
+		},
+		"limit-zero": {
+			ctx:   context.Background(),
+			query: `SELECT id FROM "users" LIMIT 0`,
+			want: want{
+				limit: aws.Int32(1),
+			},
+		},
+		"huge-offset": {
+			ctx:   context.Background(),
+			query: `SELECT id FROM "users" LIMIT 1 OFFSET 9223372036854775807`,
+			want: want{
+				calls: 3,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				input := &dynamodb.ExecuteStatementInput{Statement: aws.String(`SELECT id FROM "users"`), Limit: tt.want.limit}
+				WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{Items: page("1", "2", "3"), NextToken: aws.String("1")}, nil).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{Items: page("4", "5", "6"), NextToken: aws.String("2")}, nil).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{Items: page("7", "8", "9")}, nil).
+					Verify(Times(tt.want.calls))
+
+				sut := newConnection(client)
+				rows, err := sut.QueryContext(tt.ctx, tt.query, nil)
+				if err != nil {
+					t.Fatalf("QueryContext() unexpected error = %v", err)
+				}
+				defer rows.Close()
+				resultSets, _ := GetAllResultSet(t, rows)
+				var ids []string
+				for _, resultSet := range resultSets {
+					for _, item := range resultSet {
+						ids = append(ids, item["id"].(*types.AttributeValueMemberS).Value)
+					}
+				}
+				if diff := cmp.Diff(tt.want.ids, ids); diff != "" {
+					t.Errorf("ids mismatch (-want +got):\n%s", diff)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_QueryContext_with_NextToken(t *testing.T) {
+	type test struct {
+		options     []ConnectorOption
+		resumeQuery string
+		wantErr     error
+	}
+
+	query := `SELECT id FROM "users"`
+	tests := map[string]test{
+		"raw": {
+			resumeQuery: query,
+		},
+		"opaque": {
+			options:     []ConnectorOption{WithOpaqueNextToken([]byte("secret"))},
+			resumeQuery: query,
+		},
+		"opaque-with-another-statement": {
+			options:     []ConnectorOption{WithOpaqueNextToken([]byte("secret"))},
+			resumeQuery: `SELECT id, name FROM "users"`,
+			wantErr:     ErrInvalidNextToken,
+		},
+	}
+
+	cmpOpts := []cmp.Option{cmpopts.IgnoreUnexported(dynamodb.ExecuteStatementInput{})}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(
+					client.ExecuteStatement(
+						AnyContext(), InputEqual(&dynamodb.ExecuteStatementInput{Statement: aws.String(query)}, cmpOpts...)(),
+					),
+				).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{NextToken: aws.String("token-1")}, nil).
+					Verify(Times(1))
+				WhenDouble(
+					client.ExecuteStatement(
+						AnyContext(),
+						InputEqual(
+							&dynamodb.ExecuteStatementInput{Statement: aws.String(tt.resumeQuery), NextToken: aws.String("token-1")},
+							cmpOpts...,
+						)(),
+					),
+				).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil)
+
+				ctx := context.Background()
+				sut := newConnection(client, tt.options...)
+
+				var cursor Cursor
+				rows, err := sut.QueryContext(WithCursor(ctx, &cursor), query, nil)
+				if err != nil {
+					t.Fatalf("QueryContext() unexpected error = %v", err)
+				}
+				token := cursor.NextToken()
+				if token == "" {
+					t.Fatalf("Cursor.NextToken() is empty")
+				}
+				if got := rows.(RowsNextToken).NextToken(); got != token {
+					t.Errorf("RowsNextToken.NextToken() = %q, want %q", got, token)
+				}
+
+				resumed, err := sut.QueryContext(WithNextToken(WithCursor(ctx, &cursor), token), tt.resumeQuery, nil)
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("QueryContext() error = %v, want %v", err, tt.wantErr)
+				}
+				if err != nil {
+					return
+				}
+				if resumed == nil {
+					t.Fatalf("QueryContext() returned nil rows")
+				}
+				if got := cursor.NextToken(); got != "" {
+					t.Errorf("Cursor.NextToken() = %q, want empty", got)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_QueryContext_with_nested_paths(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	input := &dynamodb.ExecuteStatementInput{
+		Statement: aws.String(`SELECT id, address, tags FROM "users"`),
+	}
+	WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{
+					{
+						"id": &types.AttributeValueMemberS{Value: "1"},
+						"address": &types.AttributeValueMemberM{
+							Value: map[string]types.AttributeValue{
+								"city": &types.AttributeValueMemberS{Value: "Tokyo"},
+							},
+						},
+						"tags": &types.AttributeValueMemberL{
+							Value: []types.AttributeValue{
+								&types.AttributeValueMemberS{Value: "a"},
+								&types.AttributeValueMemberS{Value: "b"},
+							},
+						},
+					},
+				},
+			}, nil,
+		).
+		Verify(Times(1))
+
+	db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client)))
+	defer db.Close()
+
+	rows, err := db.QueryContext(
+		context.Background(), `SELECT id AS user_id, address.city AS city, address.zip, tags[1], tags[2] FROM "users"`,
+	)
+	if err != nil {
+		t.Fatalf("QueryContext() unexpected error = %v", err)
+	}
+	defer rows.Close()
+
+	columns, err := rows.Columns()
+	if err != nil {
+		t.Fatalf("Columns() unexpected error = %v", err)
+	}
+	if diff := cmp.Diff([]string{"user_id", "city", "address.zip", "tags[1]", "tags[2]"}, columns); diff != "" {
+		t.Errorf("Columns() mismatch (-want +got):\n%s", diff)
+	}
+	if !rows.Next() {
+		t.Fatalf("Next() = false, err = %v", rows.Err())
+	}
+	var (
+		id, city, tag   string
+		zip, missingTag sql.NullString
+	)
+	if err := rows.Scan(&id, &city, &zip, &tag, &missingTag); err != nil {
+		t.Fatalf("Scan() unexpected error = %v", err)
+	}
+	if id != "1" || city != "Tokyo" || tag != "b" || zip.Valid || missingTag.Valid {
+		t.Errorf("Scan() = %q, %q, %v, %q, %v", id, city, zip, tag, missingTag)
+	}
+}
@@ -0,0 +1,186 @@
+package pqxd
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// returnValuesOnConditionCheckFailureKey is the context key for WithReturnValuesOnConditionCheckFailure
+type returnValuesOnConditionCheckFailureKey struct{}
+
+// WithReturnValuesOnConditionCheckFailure returns a new context that requests the item
+// which failed the condition check of the write statement.
+//
+// The item is available in CancellationReason.Item of TransactionCanceledError or BatchStatementError.Item.
+func WithReturnValuesOnConditionCheckFailure(ctx context.Context) context.Context {
+	return context.WithValue(ctx, returnValuesOnConditionCheckFailureKey{}, true)
+}
+
+// returnValuesOnConditionCheckFailureFromContext returns ReturnValuesOnConditionCheckFailure requested by the context.
+func returnValuesOnConditionCheckFailureFromContext(ctx context.Context) types.ReturnValuesOnConditionCheckFailure {
+	if v, _ := ctx.Value(returnValuesOnConditionCheckFailureKey{}).(bool); v {
+		return types.ReturnValuesOnConditionCheckFailureAllOld
+	}
+	return ""
+}
+
+// clientRequestTokenKey is the context key for WithClientRequestToken
+type clientRequestTokenKey struct{}
+
+// WithClientRequestToken returns a new context that specifies the ClientRequestToken of the transaction.
+// The context must be passed to sql.DB.BeginTx.
+//
+// If not specified, a random token is generated for each transaction.
+// Committing transactions with the same token within 10 minutes is idempotent.
+func WithClientRequestToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, clientRequestTokenKey{}, token)
+}
+
+// clientRequestTokenFromContext returns the ClientRequestToken specified by the context. empty if not specified.
+func clientRequestTokenFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(clientRequestTokenKey{}).(string)
+	return v
+}
+
+// newClientRequestToken returns a new random ClientRequestToken
+func newClientRequestToken() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// consistentReadKey is the context key for WithConsistentRead
+type consistentReadKey struct{}
+
+// WithConsistentRead returns a new context that specifies whether the SELECT statement uses strongly consistent reads.
+// It overrides the default of the connector set by WithDefaultConsistentRead.
+//
+// Querying a global secondary index with enabled returns ErrConsistentReadOnIndex.
+func WithConsistentRead(ctx context.Context, enabled bool) context.Context {
+	return context.WithValue(ctx, consistentReadKey{}, enabled)
+}
+
+// consistentReadFromContext returns whether consistent reads are specified by the context.
+// ok is false if not specified.
+func consistentReadFromContext(ctx context.Context) (enabled bool, ok bool) {
+	enabled, ok = ctx.Value(consistentReadKey{}).(bool)
+	return
+}
+
+// pageSizeKey is the context key for WithPageSize
+type pageSizeKey struct{}
+
+// WithPageSize returns a new context that specifies the maximum number of items evaluated per page of the SELECT statement.
+// It overrides the default of the connector set by WithDefaultPageSize. Zero or less means no limit.
+//
+// The page size applies to every page fetched by driver.RowsNextResultSet.
+func WithPageSize(ctx context.Context, size int32) context.Context {
+	return context.WithValue(ctx, pageSizeKey{}, size)
+}
+
+// pageSizeFromContext returns the page size specified by the context. ok is false if not specified.
+func pageSizeFromContext(ctx context.Context) (size int32, ok bool) {
+	size, ok = ctx.Value(pageSizeKey{}).(int32)
+	return
+}
+
+// nextTokenKey is the context key for WithNextToken
+type nextTokenKey struct{}
+
+// WithNextToken returns a new context that specifies the token to start fetching the SELECT statement from.
+// The token is the one returned by Cursor.NextToken or RowsNextToken.NextToken for the same statement.
+//
+// If the connector is set up with WithOpaqueNextToken, a token issued for another statement returns ErrInvalidNextToken.
+func WithNextToken(ctx context.Context, token string) context.Context {
+	return context.WithValue(ctx, nextTokenKey{}, token)
+}
+
+// nextTokenFromContext returns the token specified by the context. empty if not specified.
+func nextTokenFromContext(ctx context.Context) string {
+	v, _ := ctx.Value(nextTokenKey{}).(string)
+	return v
+}
+
+// cursorKey is the context key for WithCursor
+type cursorKey struct{}
+
+// WithCursor returns a new context that receives the NextToken of the rows of the SELECT statement into cursor.
+func WithCursor(ctx context.Context, cursor *Cursor) context.Context {
+	return context.WithValue(ctx, cursorKey{}, cursor)
+}
+
+// cursorFromContext returns the Cursor specified by the context. nil if not specified.
+func cursorFromContext(ctx context.Context) *Cursor {
+	v, _ := ctx.Value(cursorKey{}).(*Cursor)
+	return v
+}
+
+// capacityCollectorKey is the context key for WithCapacityCollector
+type capacityCollectorKey struct{}
+
+// WithCapacityCollector returns a new context that collects the capacity consumed by the statements into collector.
+//
+// Passed to sql.DB.BeginTx, it collects the capacity consumed by the transaction.
+func WithCapacityCollector(ctx context.Context, collector *CapacityCollector) context.Context {
+	return context.WithValue(ctx, capacityCollectorKey{}, collector)
+}
+
+// capacityCollectorFromContext returns the CapacityCollector specified by the context. nil if not specified.
+func capacityCollectorFromContext(ctx context.Context) *CapacityCollector {
+	v, _ := ctx.Value(capacityCollectorKey{}).(*CapacityCollector)
+	return v
+}
+
+// capacityBudgetKey is the context key for WithCapacityBudget
+type capacityBudgetKey struct{}
+
+// WithCapacityBudget returns a new context that limits the read capacity units consumed by the SELECT statements
+// executed with the context. The budget is shared by all statements executed with the context.
+//
+// The budget is checked before each page is fetched, including the first one, since the capacity consumed by a page
+// is not known until it is returned. Once the budget is spent, executing a statement or fetching the next page
+// with driver.RowsNextResultSet returns ErrCapacityBudgetExceeded, and the last page may have exceeded the budget.
+func WithCapacityBudget(ctx context.Context, units float64) context.Context {
+	return context.WithValue(ctx, capacityBudgetKey{}, &capacityBudget{limit: units})
+}
+
+// capacityBudgetFromContext returns the capacityBudget specified by the context. nil if not specified.
+func capacityBudgetFromContext(ctx context.Context) *capacityBudget {
+	v, _ := ctx.Value(capacityBudgetKey{}).(*capacityBudget)
+	return v
+}
+
+// scanAllowedKey is the context key for WithScanAllowed
+type scanAllowedKey struct{}
+
+// WithScanAllowed returns a new context that allows the statements executed with the context to scan
+// a whole table or index regardless of ScanPolicy.
+func WithScanAllowed(ctx context.Context) context.Context {
+	return context.WithValue(ctx, scanAllowedKey{}, true)
+}
+
+// scanAllowedFromContext reports whether the context allows scans.
+func scanAllowedFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(scanAllowedKey{}).(bool)
+	return v
+}
+
+// attributeValuesKey is the context key for WithAttributeValues
+type attributeValuesKey struct{}
+
+// WithAttributeValues returns a new context that makes the rows of the SELECT statement return the columns
+// as types.AttributeValue as they are, instead of the values decoded following NumberMode and WithTypeConverter.
+//
+// ScanStruct and ScanAll decode such rows without loss of precision. Query uses it implicitly.
+func WithAttributeValues(ctx context.Context) context.Context {
+	return context.WithValue(ctx, attributeValuesKey{}, true)
+}
+
+// attributeValuesFromContext reports whether the context requests the attribute values as they are.
+func attributeValuesFromContext(ctx context.Context) bool {
+	v, _ := ctx.Value(attributeValuesKey{}).(bool)
+	return v
+}
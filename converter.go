@@ -0,0 +1,78 @@
+package pqxd
+
+import (
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// TypeConverter converts the values of T to and from the attribute values of DynamoDB.
+type TypeConverter[T any] struct {
+	// Encode converts a parameter of T into an attribute value. nil if T is encoded as usual.
+	Encode func(v T) (types.AttributeValue, error)
+
+	// Decode converts an attribute value returned by a statement into T.
+	// It returns false if the attribute value is not of T, so that it is tried with the next converter
+	// and finally decoded as usual. nil if nothing is decoded into T.
+	Decode func(av types.AttributeValue) (v T, ok bool, err error)
+}
+
+// WithTypeConverter registers the TypeConverter of T.
+//
+// Encode is used for the parameters whose type is exactly T, instead of driver.Valuer and the default encoding.
+// Decode is used for the columns returned by driver.Rows.Next, tried in order of registration.
+// Neither is applied to the values nested in lists and maps.
+func WithTypeConverter[T any](converter TypeConverter[T]) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		if s.typeRegistry == nil {
+			s.typeRegistry = &typeRegistry{encoders: make(map[reflect.Type]func(any) (types.AttributeValue, error))}
+		}
+		if converter.Encode != nil {
+			s.typeRegistry.encoders[reflect.TypeFor[T]()] = func(v any) (types.AttributeValue, error) {
+				return converter.Encode(v.(T))
+			}
+		}
+		if converter.Decode != nil {
+			s.typeRegistry.decoders = append(
+				s.typeRegistry.decoders, func(av types.AttributeValue) (any, bool, error) {
+					return converter.Decode(av)
+				},
+			)
+		}
+	}
+}
+
+// typeRegistry is the set of the TypeConverter registered in the connector.
+type typeRegistry struct {
+	// encoders are the encoders per Go type
+	encoders map[reflect.Type]func(any) (types.AttributeValue, error)
+
+	// decoders are the decoders in order of registration
+	decoders []func(types.AttributeValue) (any, bool, error)
+}
+
+// encode encodes the value with the encoder of its type. ok is false if no encoder is registered for the type.
+func (r *typeRegistry) encode(v any) (av types.AttributeValue, ok bool, err error) {
+	if r == nil || v == nil {
+		return nil, false, nil
+	}
+	encode, ok := r.encoders[reflect.TypeOf(v)]
+	if !ok {
+		return nil, false, nil
+	}
+	av, err = encode(v)
+	return av, true, err
+}
+
+// decode decodes the attribute value with the first decoder that accepts it. ok is false if no decoder accepts it.
+func (r *typeRegistry) decode(av types.AttributeValue) (v any, ok bool, err error) {
+	if r == nil {
+		return nil, false, nil
+	}
+	for _, decode := range r.decoders {
+		if v, ok, err := decode(av); ok || err != nil {
+			return v, ok, err
+		}
+	}
+	return nil, false, nil
+}
@@ -0,0 +1,93 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+type testStatus int
+
+const (
+	testStatusActive testStatus = iota + 1
+	testStatusSuspended
+)
+
+var testStatusNames = map[testStatus]string{
+	testStatusActive:    "ACTIVE",
+	testStatusSuspended: "SUSPENDED",
+}
+
+var testStatusConverter = TypeConverter[testStatus]{
+	Encode: func(v testStatus) (types.AttributeValue, error) {
+		name, ok := testStatusNames[v]
+		if !ok {
+			return nil, fmt.Errorf("unknown status %d", v)
+		}
+		return &types.AttributeValueMemberS{Value: name}, nil
+	},
+	Decode: func(av types.AttributeValue) (testStatus, bool, error) {
+		s, ok := av.(*types.AttributeValueMemberS)
+		if !ok {
+			return 0, false, nil
+		}
+		for status, name := range testStatusNames {
+			if s.Value == name {
+				return status, true, nil
+			}
+		}
+		return 0, false, nil
+	},
+}
+
+func Test_Connection_with_TypeConverter(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	input := &dynamodb.ExecuteStatementInput{
+		Statement: aws.String(`SELECT id, status FROM "users" WHERE status = ?`),
+		Parameters: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "SUSPENDED"},
+		},
+	}
+	WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{
+					{
+						"id":     &types.AttributeValueMemberS{Value: "1"},
+						"status": &types.AttributeValueMemberS{Value: "SUSPENDED"},
+					},
+				},
+			}, nil,
+		).
+		Verify(Times(1))
+
+	db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client), WithTypeConverter(testStatusConverter)))
+	defer db.Close()
+
+	var (
+		id     string
+		status testStatus
+	)
+	row := db.QueryRowContext(context.Background(), *input.Statement, testStatusSuspended)
+	if err := row.Scan(&id, &status); err != nil {
+		t.Fatalf("Scan() unexpected error = %v", err)
+	}
+	if id != "1" {
+		t.Errorf("id = %q, want %q", id, "1")
+	}
+	if status != testStatusSuspended {
+		t.Errorf("status = %d, want %d", status, testStatusSuspended)
+	}
+
+	if _, err := db.ExecContext(context.Background(), `DELETE FROM "users" WHERE status = ?`, testStatus(0)); err == nil {
+		t.Errorf("ExecContext() with unknown status, want error")
+	}
+}
@@ -0,0 +1,86 @@
+package pqxd
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"go.uber.org/atomic"
+)
+
+// ErrInvalidNextToken occurs when the token given by WithNextToken is malformed or issued for another statement
+var ErrInvalidNextToken = errors.New("pqxd: invalid next token")
+
+// Cursor receives the NextToken of the rows of a SELECT statement.
+//
+// Example:
+//
+//	var cursor pqxd.Cursor
+//	ctx = pqxd.WithNextToken(pqxd.WithCursor(ctx, &cursor), req.PageToken)
+//	rows, err := db.QueryContext(ctx, `SELECT id, name FROM "users" WHERE status = ?`, "active")
+//	...
+//	res.NextPageToken = cursor.NextToken()
+type Cursor struct {
+	token atomic.Pointer[string]
+}
+
+// NextToken returns the token to fetch the page after the current result set. empty if no more pages.
+func (c *Cursor) NextToken() string {
+	return aws.ToString(c.token.Load())
+}
+
+// store stores the token.
+func (c *Cursor) store(token string) {
+	c.token.Store(&token)
+}
+
+// nextTokenMACSize is the size of the MAC prepended to the opaque token.
+const nextTokenMACSize = 16
+
+// nextTokenCodec encodes NextToken returned by DynamoDB into the token exposed to callers, and vice versa.
+type nextTokenCodec struct {
+	// key is the key of HMAC. if nil, tokens are exposed as is.
+	key []byte
+}
+
+// encode returns the token exposed to callers. empty if token is nil.
+func (c *nextTokenCodec) encode(statement string, token *string) string {
+	if token == nil {
+		return ""
+	}
+	if c == nil || c.key == nil {
+		return *token
+	}
+	mac := c.mac(statement, *token)
+	return base64.RawURLEncoding.EncodeToString(append(mac, *token...))
+}
+
+// decode returns NextToken for DynamoDB from the token given by callers. nil if token is empty.
+func (c *nextTokenCodec) decode(statement string, token string) (*string, error) {
+	if token == "" {
+		return nil, nil
+	}
+	if c == nil || c.key == nil {
+		return &token, nil
+	}
+	b, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil || len(b) <= nextTokenMACSize {
+		return nil, ErrInvalidNextToken
+	}
+	raw := string(b[nextTokenMACSize:])
+	if !hmac.Equal(b[:nextTokenMACSize], c.mac(statement, raw)) {
+		return nil, ErrInvalidNextToken
+	}
+	return &raw, nil
+}
+
+// mac returns the MAC binding the token to the statement.
+func (c *nextTokenCodec) mac(statement, token string) []byte {
+	h := hmac.New(sha256.New, c.key)
+	h.Write([]byte(statement))
+	h.Write([]byte{0})
+	h.Write([]byte(token))
+	return h.Sum(nil)[:nextTokenMACSize]
+}
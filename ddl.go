@@ -0,0 +1,315 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/miyamo2/pqxd/internal/partiql"
+)
+
+// ddlWaitInterval is the interval of DescribeTable API calls while waiting for a DDL statement with WAIT.
+var ddlWaitInterval = 2 * time.Second
+
+// DDL options
+const (
+	// ddlOptionBillingMode is the billing mode of the table. PAY_PER_REQUEST or PROVISIONED.
+	ddlOptionBillingMode = "BILLING_MODE"
+
+	// ddlOptionReadCapacity is the provisioned read capacity units.
+	ddlOptionReadCapacity = "READ_CAPACITY"
+
+	// ddlOptionWriteCapacity is the provisioned write capacity units.
+	ddlOptionWriteCapacity = "WRITE_CAPACITY"
+
+	// ddlOptionProjection is the projection type of the index. ALL or KEYS_ONLY.
+	ddlOptionProjection = "PROJECTION"
+)
+
+// ddlOperation is a DDL statement translated into a control-plane API call.
+type ddlOperation struct {
+	// tableName is the name of the target table
+	tableName string
+
+	// createTable is the input of CreateTable API. nil if not CREATE TABLE.
+	createTable *dynamodb.CreateTableInput
+
+	// updateTable is the input of UpdateTable API. nil if not ALTER TABLE.
+	updateTable *dynamodb.UpdateTableInput
+
+	// deleteTable is the input of DeleteTable API. nil if not DROP TABLE.
+	deleteTable *dynamodb.DeleteTableInput
+
+	// wait if true, blocks until the operation is completed
+	wait bool
+
+	// completed reports whether the operation is completed. table is nil if the table does not exist.
+	completed func(table *types.TableDescription) bool
+}
+
+// newDDLOperation returns a new ddlOperation from the DDL statement
+func newDDLOperation(stmt partiql.Statement) (*ddlOperation, error) {
+	switch stmt := stmt.(type) {
+	case *partiql.CreateTableStatement:
+		return newCreateTableOperation(stmt)
+	case *partiql.DropTableStatement:
+		return &ddlOperation{
+			tableName:   stmt.Table.Name,
+			deleteTable: &dynamodb.DeleteTableInput{TableName: aws.String(stmt.Table.Name)},
+			wait:        stmt.Wait,
+			completed: func(table *types.TableDescription) bool {
+				return table == nil
+			},
+		}, nil
+	case *partiql.AlterTableStatement:
+		return newAlterTableOperation(stmt)
+	}
+	return nil, nil
+}
+
+// newCreateTableOperation returns a new ddlOperation for CREATE TABLE
+func newCreateTableOperation(stmt *partiql.CreateTableStatement) (*ddlOperation, error) {
+	input := &dynamodb.CreateTableInput{
+		TableName:            aws.String(stmt.Table.Name),
+		KeySchema:            toKeySchema(stmt.Keys),
+		AttributeDefinitions: toAttributeDefinitions(stmt.Keys),
+		BillingMode:          types.BillingModePayPerRequest,
+	}
+	var throughput types.ProvisionedThroughput
+	for _, opt := range stmt.Options {
+		switch opt.Name {
+		case ddlOptionBillingMode:
+			mode := types.BillingMode(strings.ToUpper(opt.Value))
+			if !slices.Contains(mode.Values(), mode) {
+				return nil, newSyntaxError(opt.Pos(), "invalid %s: %s", opt.Name, opt.Value)
+			}
+			input.BillingMode = mode
+		case ddlOptionReadCapacity:
+			v, err := parseCapacityOption(opt)
+			if err != nil {
+				return nil, err
+			}
+			throughput.ReadCapacityUnits = v
+		case ddlOptionWriteCapacity:
+			v, err := parseCapacityOption(opt)
+			if err != nil {
+				return nil, err
+			}
+			throughput.WriteCapacityUnits = v
+		default:
+			return nil, newSyntaxError(opt.Pos(), "unknown option %s", opt.Name)
+		}
+	}
+	if input.BillingMode == types.BillingModeProvisioned {
+		if throughput.ReadCapacityUnits == nil || throughput.WriteCapacityUnits == nil {
+			return nil, newSyntaxError(
+				stmt.Pos(), "%s=%s requires %s and %s",
+				ddlOptionBillingMode, types.BillingModeProvisioned, ddlOptionReadCapacity, ddlOptionWriteCapacity,
+			)
+		}
+		input.ProvisionedThroughput = &throughput
+	}
+	return &ddlOperation{
+		tableName:   stmt.Table.Name,
+		createTable: input,
+		wait:        stmt.Wait,
+		completed: func(table *types.TableDescription) bool {
+			return table != nil && table.TableStatus == types.TableStatusActive
+		},
+	}, nil
+}
+
+// newAlterTableOperation returns a new ddlOperation for ALTER TABLE
+func newAlterTableOperation(stmt *partiql.AlterTableStatement) (*ddlOperation, error) {
+	input := &dynamodb.UpdateTableInput{TableName: aws.String(stmt.Table.Name)}
+	op := &ddlOperation{
+		tableName:   stmt.Table.Name,
+		updateTable: input,
+		wait:        stmt.Wait,
+	}
+
+	if stmt.DropIndex != "" {
+		indexName := stmt.DropIndex
+		input.GlobalSecondaryIndexUpdates = []types.GlobalSecondaryIndexUpdate{
+			{Delete: &types.DeleteGlobalSecondaryIndexAction{IndexName: aws.String(indexName)}},
+		}
+		op.completed = func(table *types.TableDescription) bool {
+			if table == nil || table.TableStatus != types.TableStatusActive {
+				return false
+			}
+			return !slices.ContainsFunc(
+				table.GlobalSecondaryIndexes, func(v types.GlobalSecondaryIndexDescription) bool {
+					return aws.ToString(v.IndexName) == indexName
+				},
+			)
+		}
+		return op, nil
+	}
+
+	index := stmt.AddIndex
+	action := &types.CreateGlobalSecondaryIndexAction{
+		IndexName:  aws.String(index.Name),
+		KeySchema:  toKeySchema(index.Keys),
+		Projection: &types.Projection{ProjectionType: types.ProjectionTypeAll},
+	}
+	var throughput types.ProvisionedThroughput
+	for _, opt := range index.Options {
+		switch opt.Name {
+		case ddlOptionProjection:
+			projectionType := types.ProjectionType(strings.ToUpper(opt.Value))
+			if projectionType != types.ProjectionTypeAll && projectionType != types.ProjectionTypeKeysOnly {
+				return nil, newSyntaxError(opt.Pos(), "invalid %s: %s", opt.Name, opt.Value)
+			}
+			action.Projection.ProjectionType = projectionType
+		case ddlOptionReadCapacity:
+			v, err := parseCapacityOption(opt)
+			if err != nil {
+				return nil, err
+			}
+			throughput.ReadCapacityUnits = v
+		case ddlOptionWriteCapacity:
+			v, err := parseCapacityOption(opt)
+			if err != nil {
+				return nil, err
+			}
+			throughput.WriteCapacityUnits = v
+		default:
+			return nil, newSyntaxError(opt.Pos(), "unknown option %s", opt.Name)
+		}
+	}
+	if (throughput.ReadCapacityUnits == nil) != (throughput.WriteCapacityUnits == nil) {
+		return nil, newSyntaxError(
+			index.Pos(), "%s and %s must be specified together", ddlOptionReadCapacity, ddlOptionWriteCapacity,
+		)
+	}
+	if throughput.ReadCapacityUnits != nil {
+		action.ProvisionedThroughput = &throughput
+	}
+	input.AttributeDefinitions = toAttributeDefinitions(index.Keys)
+	input.GlobalSecondaryIndexUpdates = []types.GlobalSecondaryIndexUpdate{{Create: action}}
+	op.completed = func(table *types.TableDescription) bool {
+		if table == nil || table.TableStatus != types.TableStatusActive {
+			return false
+		}
+		return slices.ContainsFunc(
+			table.GlobalSecondaryIndexes, func(v types.GlobalSecondaryIndexDescription) bool {
+				return aws.ToString(v.IndexName) == index.Name && v.IndexStatus == types.IndexStatusActive
+			},
+		)
+	}
+	return op, nil
+}
+
+// parseCapacityOption parses the value of READ_CAPACITY or WRITE_CAPACITY
+func parseCapacityOption(opt *partiql.Option) (*int64, error) {
+	v, err := strconv.ParseInt(opt.Value, 10, 64)
+	if err != nil || v <= 0 {
+		return nil, newSyntaxError(opt.Pos(), "invalid %s: %s", opt.Name, opt.Value)
+	}
+	return &v, nil
+}
+
+// toKeySchema converts key definitions to []types.KeySchemaElement
+func toKeySchema(keys []*partiql.KeyDefinition) []types.KeySchemaElement {
+	schema := make([]types.KeySchemaElement, 0, len(keys))
+	for _, key := range keys {
+		schema = append(
+			schema, types.KeySchemaElement{
+				AttributeName: aws.String(key.Name),
+				KeyType:       types.KeyType(key.KeyType),
+			},
+		)
+	}
+	// DynamoDB requires the HASH key to come first.
+	slices.SortStableFunc(
+		schema, func(a, b types.KeySchemaElement) int {
+			if a.KeyType == b.KeyType {
+				return 0
+			}
+			if a.KeyType == types.KeyTypeHash {
+				return -1
+			}
+			return 1
+		},
+	)
+	return schema
+}
+
+// toAttributeDefinitions converts key definitions to []types.AttributeDefinition
+func toAttributeDefinitions(keys []*partiql.KeyDefinition) []types.AttributeDefinition {
+	definitions := make([]types.AttributeDefinition, 0, len(keys))
+	for _, key := range keys {
+		definitions = append(
+			definitions, types.AttributeDefinition{
+				AttributeName: aws.String(key.Name),
+				AttributeType: types.ScalarAttributeType(key.Type),
+			},
+		)
+	}
+	return definitions
+}
+
+// execDDL performs the control-plane API of the DDL operation.
+func (c *connection) execDDL(ctx context.Context, op *ddlOperation) (driver.Result, error) {
+	if c.closed.Load() {
+		return nil, driver.ErrBadConn
+	}
+	if c.txOngoing.Load() {
+		return nil, ErrNotSupportedWithinTx
+	}
+
+	var err error
+	switch {
+	case op.createTable != nil:
+		_, err = c.client.CreateTable(ctx, op.createTable)
+	case op.updateTable != nil:
+		_, err = c.client.UpdateTable(ctx, op.updateTable)
+	case op.deleteTable != nil:
+		_, err = c.client.DeleteTable(ctx, op.deleteTable)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// the cached schema is stale once the operation is accepted, and again once it is completed.
+	c.setting.schemaCache.invalidate(op.tableName)
+	defer c.setting.schemaCache.invalidate(op.tableName)
+	if op.wait {
+		if err := c.waitForDDL(ctx, op); err != nil {
+			return nil, err
+		}
+	}
+	return newPqxdResult(0), nil
+}
+
+// waitForDDL polls DescribeTable API until the DDL operation is completed.
+func (c *connection) waitForDDL(ctx context.Context, op *ddlOperation) error {
+	ticker := time.NewTicker(ddlWaitInterval)
+	defer ticker.Stop()
+	for {
+		var table *types.TableDescription
+		output, err := c.client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: &op.tableName})
+		var notFound *types.ResourceNotFoundException
+		switch {
+		case errors.As(err, &notFound):
+		case err != nil:
+			return err
+		case output != nil:
+			table = output.Table
+		}
+		if op.completed(table) {
+			return nil
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+}
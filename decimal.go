@@ -0,0 +1,259 @@
+package pqxd
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// compatibility check
+var (
+	_ sql.Scanner   = (*Decimal)(nil)
+	_ driver.Valuer = Decimal{}
+	_ fmt.Stringer  = Decimal{}
+
+	_ attributevalue.Marshaler   = Decimal{}
+	_ attributevalue.Unmarshaler = (*Decimal)(nil)
+)
+
+// decimalFloatPrec is the precision of big.Float converted from Decimal.
+// At 128 bits, every number of DynamoDB, up to 38 significant digits, round-trips through its decimal string.
+const decimalFloatPrec = 128
+
+// Decimal is an arbitrary-precision decimal number, which holds N attributes of DynamoDB without loss.
+//
+// The zero value is 0.
+type Decimal struct {
+	// unscaled is the coefficient. nil if zero.
+	unscaled *big.Int
+
+	// scale is the number of digits after the decimal point. The value is unscaled * 10^-scale.
+	scale int32
+}
+
+// ParseDecimal parses a number in decimal notation, optionally with an exponent. e.g. -1.5, 1E+10
+func ParseDecimal(s string) (Decimal, error) {
+	invalid := func() (Decimal, error) {
+		return Decimal{}, fmt.Errorf("pqxd: invalid number %q", s)
+	}
+	mantissa, exponent := s, int64(0)
+	if i := strings.IndexAny(s, "eE"); i >= 0 {
+		e, err := strconv.ParseInt(s[i+1:], 10, 32)
+		if err != nil {
+			return invalid()
+		}
+		mantissa, exponent = s[:i], e
+	}
+	sign := ""
+	if mantissa != "" && (mantissa[0] == '-' || mantissa[0] == '+') {
+		sign, mantissa = mantissa[:1], mantissa[1:]
+	}
+	integer, fraction, _ := strings.Cut(mantissa, ".")
+	digits := integer + fraction
+	if digits == "" || strings.Trim(digits, "0123456789") != "" {
+		return invalid()
+	}
+	scale := int64(len(fraction)) - exponent
+	if scale < math.MinInt32 || scale > math.MaxInt32 {
+		return invalid()
+	}
+	unscaled, ok := new(big.Int).SetString(sign+digits, 10)
+	if !ok {
+		return invalid()
+	}
+	return Decimal{unscaled: unscaled, scale: int32(scale)}, nil
+}
+
+// coefficient returns the coefficient. never nil.
+func (d Decimal) coefficient() *big.Int {
+	if d.unscaled == nil {
+		return new(big.Int)
+	}
+	return d.unscaled
+}
+
+// String returns the number in decimal notation without an exponent.
+func (d Decimal) String() string {
+	unscaled := d.coefficient()
+	digits := new(big.Int).Abs(unscaled).String()
+	var sb strings.Builder
+	if unscaled.Sign() < 0 {
+		sb.WriteString("-")
+	}
+	switch {
+	case d.scale <= 0:
+		sb.WriteString(digits)
+		if unscaled.Sign() != 0 {
+			sb.WriteString(strings.Repeat("0", int(-d.scale)))
+		}
+	default:
+		scale := int(d.scale)
+		if len(digits) <= scale {
+			digits = strings.Repeat("0", scale-len(digits)+1) + digits
+		}
+		sb.WriteString(digits[:len(digits)-scale])
+		sb.WriteString(".")
+		sb.WriteString(digits[len(digits)-scale:])
+	}
+	return sb.String()
+}
+
+// BigInt returns the number as *big.Int. It returns ErrInexactNumber if the number has a fractional part.
+func (d Decimal) BigInt() (*big.Int, error) {
+	unscaled := d.coefficient()
+	if d.scale <= 0 {
+		pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(-d.scale)), nil)
+		return pow.Mul(pow, unscaled), nil
+	}
+	pow := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(d.scale)), nil)
+	quo, rem := new(big.Int).QuoRem(unscaled, pow, new(big.Int))
+	if rem.Sign() != 0 {
+		return nil, fmt.Errorf("%w: %s is not an integer", ErrInexactNumber, d)
+	}
+	return quo, nil
+}
+
+// Int64 returns the number as int64.
+// It returns ErrInexactNumber if the number has a fractional part, and ErrNumberOutOfRange if it overflows.
+func (d Decimal) Int64() (int64, error) {
+	v, err := d.BigInt()
+	if err != nil {
+		return 0, err
+	}
+	if !v.IsInt64() {
+		return 0, fmt.Errorf("%w: %s overflows int64", ErrNumberOutOfRange, d)
+	}
+	return v.Int64(), nil
+}
+
+// BigFloat returns the number as *big.Float with 128 bits of precision.
+func (d Decimal) BigFloat() *big.Float {
+	f, _, _ := big.ParseFloat(d.String(), 10, decimalFloatPrec, big.ToNearestEven)
+	return f
+}
+
+// Scan implements the sql.Scanner interface.
+func (d *Decimal) Scan(src any) error {
+	v, err := decimalFromValue(src)
+	if err != nil {
+		return err
+	}
+	*d = v
+	return nil
+}
+
+// Value implements the driver.Value interface.
+// Given to the connection of pqxd directly, Decimal is sent as N attribute.
+func (d Decimal) Value() (driver.Value, error) {
+	return d.String(), nil
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (d Decimal) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return &types.AttributeValueMemberN{Value: d.String()}, nil
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (d *Decimal) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	switch v := av.(type) {
+	case *types.AttributeValueMemberN:
+		return d.Scan(v.Value)
+	case *types.AttributeValueMemberS:
+		return d.Scan(v.Value)
+	case *types.AttributeValueMemberNULL:
+		*d = Decimal{}
+		return nil
+	}
+	return fmt.Errorf("pqxd: converting %T to number is unsupported", av)
+}
+
+// decimalFromValue converts the value returned by driver.Rows.Next to Decimal.
+func decimalFromValue(src any) (Decimal, error) {
+	switch v := src.(type) {
+	case Decimal:
+		return v, nil
+	case string:
+		return ParseDecimal(v)
+	case []byte:
+		return ParseDecimal(string(v))
+	case json.Number:
+		return ParseDecimal(v.String())
+	case float64:
+		return ParseDecimal(strconv.FormatFloat(v, 'g', -1, 64))
+	case int64:
+		return Decimal{unscaled: big.NewInt(v)}, nil
+	case nil:
+		return Decimal{}, fmt.Errorf("pqxd: converting NULL to number is unsupported")
+	}
+	return Decimal{}, fmt.Errorf("pqxd: converting %T to number is unsupported", src)
+}
+
+// bigIntScanner is sql.Scanner for *big.Int
+type bigIntScanner big.Int
+
+// BigInt returns sql.Scanner that scans a number into dst exactly.
+//
+// Scanning a number with a fractional part returns ErrInexactNumber.
+// Since database/sql does not convert values into *big.Int, it should be passed to sql.Rows.Scan instead of dst.
+//
+// Example:
+//
+//	var id big.Int
+//	err := row.Scan(pqxd.BigInt(&id))
+func BigInt(dst *big.Int) sql.Scanner {
+	return (*bigIntScanner)(dst)
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *bigIntScanner) Scan(src any) error {
+	d, err := decimalFromValue(src)
+	if err != nil {
+		return err
+	}
+	v, err := d.BigInt()
+	if err != nil {
+		return err
+	}
+	(*big.Int)(s).Set(v)
+	return nil
+}
+
+// bigFloatScanner is sql.Scanner for *big.Float
+type bigFloatScanner big.Float
+
+// BigFloat returns sql.Scanner that scans a number into dst.
+//
+// The number is rounded to the precision of dst, or 128 bits if dst has no precision.
+// Since database/sql does not convert values into *big.Float, it should be passed to sql.Rows.Scan instead of dst.
+//
+// Example:
+//
+//	var amount big.Float
+//	err := row.Scan(pqxd.BigFloat(&amount))
+func BigFloat(dst *big.Float) sql.Scanner {
+	return (*bigFloatScanner)(dst)
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *bigFloatScanner) Scan(src any) error {
+	d, err := decimalFromValue(src)
+	if err != nil {
+		return err
+	}
+	f := (*big.Float)(s)
+	if f.Prec() == 0 {
+		f.SetPrec(decimalFloatPrec)
+	}
+	if _, _, err := f.Parse(d.String(), 10); err != nil {
+		return fmt.Errorf("%w: %s", ErrNumberOutOfRange, d)
+	}
+	return nil
+}
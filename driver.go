@@ -4,14 +4,18 @@ import (
 	"context"
 	"database/sql"
 	"database/sql/driver"
+	"fmt"
 	"os"
 	"slices"
+	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/credentials"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
 func init() {
@@ -28,6 +32,43 @@ var (
 // ConnectorSetting is the setting for the connector.
 type ConnectorSetting struct {
 	client DynamoDBClient
+
+	// txRetryPolicy is the policy for retrying the commit of a transaction
+	txRetryPolicy RetryPolicy
+
+	// consistentRead if true, SELECT statements use strongly consistent reads by default
+	consistentRead bool
+
+	// pageSize is the default maximum number of items evaluated per page. zero if not limited.
+	pageSize int32
+
+	// nextTokenCodec encodes NextToken exposed to callers. nil if exposed as is.
+	nextTokenCodec *nextTokenCodec
+
+	// returnConsumedCapacity is ReturnConsumedCapacity of the requests. empty if not specified.
+	returnConsumedCapacity types.ReturnConsumedCapacity
+
+	// capacityCounter counts the capacity consumed through the connector. nil if not specified.
+	capacityCounter *CapacityCounter
+
+	// scanPolicy is the policy for statements that scan a whole table or index
+	scanPolicy ScanPolicy
+
+	// numberMode is the Go type of N attributes returned by driver.Rows.Next
+	numberMode NumberMode
+
+	// timeEncoding is the encoding of time.Time parameters
+	timeEncoding TimeEncoding
+
+	// aggregateRowLimit is the maximum number of items read by an aggregated SELECT statement. zero if not limited.
+	aggregateRowLimit int
+
+	// typeRegistry is the set of the TypeConverter. nil if none is registered.
+	typeRegistry *typeRegistry
+
+	// schemaCache caches the table schemas described through the connector.
+	// shared by all connections of the connector.
+	schemaCache *tableSchemaCache
 }
 
 // ConnectorOption is the option for the connector.
@@ -40,26 +81,149 @@ func WithDynamoDBClient(client DynamoDBClient) ConnectorOption {
 	}
 }
 
-// NewConnector creates a new connector with the given aws.Config and ConnectorOption.
-func NewConnector(awsConfig aws.Config, options ...ConnectorOption) driver.Connector {
-	var setting ConnectorSetting
+// WithTxRetryPolicy settings the policy for retrying the commit of a transaction to the connector.
+//
+// The commit is retried with the same ClientRequestToken on transaction conflicts, throttling and transport errors.
+// Default: DefaultTxRetryPolicy
+func WithTxRetryPolicy(policy RetryPolicy) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.txRetryPolicy = policy
+	}
+}
+
+// WithDefaultConsistentRead settings whether SELECT statements use strongly consistent reads by default.
+//
+// It can be overridden per query with WithConsistentRead.
+// Reads from a global secondary index are always eventually consistent, since DynamoDB does not support it.
+// Default: false
+func WithDefaultConsistentRead(enabled bool) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.consistentRead = enabled
+	}
+}
+
+// WithDefaultPageSize settings the default maximum number of items evaluated per page of SELECT statements.
+//
+// It can be overridden per query with WithPageSize. Zero or less means no limit.
+// Default: 0
+func WithDefaultPageSize(size int32) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.pageSize = size
+	}
+}
+
+// WithOpaqueNextToken settings the key for encoding NextToken exposed by Cursor and RowsNextToken.
+//
+// The encoded token is bound to the statement with HMAC-SHA256,
+// so the token given by WithNextToken for another statement returns ErrInvalidNextToken.
+// Default: NextToken is exposed as is.
+func WithOpaqueNextToken(key []byte) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.nextTokenCodec = &nextTokenCodec{key: key}
+	}
+}
+
+// WithReturnConsumedCapacity settings the level of detail of the consumed capacity returned by DynamoDB.
+// TOTAL or INDEXES enables ResultConsumedCapacity, RowsConsumedCapacity and CapacityCollector.
+//
+// NONE is overridden with TOTAL while WithCapacityCounter, WithCapacityCollector or WithCapacityBudget is used,
+// since they require the consumed capacity.
+//
+// Default: TOTAL if WithCapacityCounter, WithCapacityCollector or WithCapacityBudget is used, otherwise NONE.
+func WithReturnConsumedCapacity(mode types.ReturnConsumedCapacity) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.returnConsumedCapacity = mode
+	}
+}
+
+// WithCapacityCounter settings the CapacityCounter that counts the capacity consumed through the connector.
+func WithCapacityCounter(counter *CapacityCounter) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.capacityCounter = counter
+	}
+}
+
+// WithScanPolicy settings the policy for SELECT, UPDATE and DELETE statements that scan a whole table or index.
+//
+// The statements are classified with the key schemas of the tables, described once and cached in the connector.
+// A statement can be allowed to scan with WithScanAllowed or the hint /*+ ALLOW_SCAN */.
+// Default: ScanPolicyAllow
+func WithScanPolicy(policy ScanPolicy) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.scanPolicy = policy
+	}
+}
+
+// WithSchemaCacheTTL settings the time to live of the table schemas cached in the connector.
+//
+// The schemas are used by `!pqxd_describe_table`, EXPLAIN and WithScanPolicy,
+// and invalidated when the table is altered or dropped by the connector.
+// Zero or less disables caching.
+// Default: DefaultSchemaCacheTTL
+func WithSchemaCacheTTL(ttl time.Duration) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.schemaCache.ttl = ttl
+	}
+}
+
+// WithNumberMode settings the Go type of N attributes returned by driver.Rows.Next.
+//
+// With NumberModeFloat64, numbers beyond the precision of float64 lose their digits.
+// Default: NumberModeFloat64
+func WithNumberMode(mode NumberMode) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.numberMode = mode
+	}
+}
+
+// WithTimeEncoding settings the encoding of time.Time parameters and the columns scanned into *time.Time.
+//
+// EpochTime, EpochMillis and RFC3339Time are sent in their own encodings regardless of it.
+// Since Go 1.27, sql.Rows.Scan decodes the columns into *time.Time with it.
+// With older versions, pass the same encoding to Time instead.
+// Default: TimeEncodingRFC3339
+func WithTimeEncoding(encoding TimeEncoding) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.timeEncoding = encoding
+	}
+}
+
+// WithAggregateRowLimit settings the maximum number of items read by a SELECT statement
+// with GROUP BY or aggregate functions, which pages through all the results on the client side.
+//
+// The statement returns ErrAggregateRowLimitExceeded once it reads more items. Zero or less means no limit.
+// Default: DefaultAggregateRowLimit
+func WithAggregateRowLimit(limit int) ConnectorOption {
+	return func(s *ConnectorSetting) {
+		s.aggregateRowLimit = max(limit, 0)
+	}
+}
+
+// newConnectorSetting returns a new ConnectorSetting with the given ConnectorOption applied over the defaults.
+func newConnectorSetting(options ...ConnectorOption) *ConnectorSetting {
+	setting := &ConnectorSetting{
+		txRetryPolicy:     DefaultTxRetryPolicy,
+		aggregateRowLimit: DefaultAggregateRowLimit,
+		schemaCache:       newTableSchemaCache(DefaultSchemaCacheTTL),
+	}
 	for _, option := range options {
-		option(&setting)
+		option(setting)
 	}
+	return setting
+}
+
+// NewConnector creates a new connector with the given aws.Config and ConnectorOption.
+func NewConnector(awsConfig aws.Config, options ...ConnectorOption) driver.Connector {
+	setting := newConnectorSetting(options...)
 	if setting.client == nil {
 		setting.client = dynamodb.NewFromConfig(awsConfig)
 	}
-	var clientMap sync.Map
-	clientMap.Store(clientKey{}, setting.client)
-	return &pqxdDriver{
-		clientMap: clientMap,
-	}
+	return &pqxdDriver{setting: setting}
 }
 
-type clientKey struct{}
-
 type pqxdDriver struct {
-	clientMap    sync.Map
+	// setting is the setting of the connector. nil if the driver is not a connector.
+	setting      *ConnectorSetting
 	connectorMap sync.Map
 }
 
@@ -74,17 +238,20 @@ func (d *pqxdDriver) OpenConnector(name string) (driver.Connector, error) {
 	if _connector, ok := d.connectorMap.Load(name); ok {
 		return _connector.(driver.Connector), nil
 	}
-	opts := dynamoDBOptionsFromParams(newConnectionParam(name))
-	_connector := NewConnector(aws.Config{}, WithDynamoDBClient(dynamodb.New(opts)))
+	params := newConnectionParam(name)
+	options, err := connectorOptionsFromParams(params)
+	if err != nil {
+		return nil, err
+	}
+	opts := dynamoDBOptionsFromParams(params)
+	_connector := NewConnector(aws.Config{}, append(options, WithDynamoDBClient(dynamodb.New(opts)))...)
 	d.connectorMap.Store(name, _connector)
 	return _connector, nil
 }
 
 // Connect See: driver.Connector.
 func (d *pqxdDriver) Connect(_ context.Context) (driver.Conn, error) {
-	// This key will definitely hit.
-	client, _ := d.clientMap.Load(clientKey{})
-	return newConnection(client.(DynamoDBClient)), nil
+	return newConnectionWithSetting(d.setting), nil
 }
 
 // Driver See: driver.Connector.
@@ -98,6 +265,8 @@ const (
 	connectionStringKeyAccessKey = "AWS_ACCESS_KEY_ID"
 	connectionStringKeySecret    = "AWS_SECRET_ACCESS_KEY"
 	connectionStringEndpoint     = "ENDPOINT"
+
+	connectionStringKeyConsistentRead = "CONSISTENT_READ"
 )
 
 // environment variables key: Region
@@ -168,6 +337,19 @@ func (c connectionParam) lookup(key string) *string {
 	return &v
 }
 
+// connectorOptionsFromParams returns []ConnectorOption from the connectionParam.
+func connectorOptionsFromParams(connParam connectionParam) ([]ConnectorOption, error) {
+	var options []ConnectorOption
+	if v := connParam.lookup(connectionStringKeyConsistentRead); v != nil {
+		consistentRead, err := strconv.ParseBool(*v)
+		if err != nil {
+			return nil, fmt.Errorf("pqxd: invalid %s: %s", connectionStringKeyConsistentRead, *v)
+		}
+		options = append(options, WithDefaultConsistentRead(consistentRead))
+	}
+	return options, nil
+}
+
 // dynamoDBOptionsFromParams returns dynamodb.Options from the connectionParam.
 func dynamoDBOptionsFromParams(connParam connectionParam) dynamodb.Options {
 	region := connParam.lookupOr(
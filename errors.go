@@ -1,6 +1,12 @@
 package pqxd
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
 
 var (
 	// ErrNotSupported occurs when performed operation that is not supported in pqxd
@@ -20,4 +26,140 @@ var (
 
 	// ErrNotSupportedWithinTx occurs when performed operation that is not supported within transaction
 	ErrNotSupportedWithinTx = errors.New("pqxd: not supported within transaction")
+
+	// ErrUnsupportedIsolationLevel occurs when beginning a transaction with an isolation level other than
+	// sql.LevelDefault or sql.LevelSerializable
+	ErrUnsupportedIsolationLevel = errors.New("pqxd: unsupported isolation level")
+
+	// ErrWriteInReadOnlyTx occurs when performing a write statement in a read-only transaction
+	ErrWriteInReadOnlyTx = errors.New("pqxd: write statement in read-only transaction")
+
+	// ErrConsistentReadOnIndex occurs when querying a global secondary index with strongly consistent reads
+	ErrConsistentReadOnIndex = errors.New("pqxd: consistent reads are not supported on global secondary indexes")
+
+	// ErrInvalidArgument occurs when the arguments do not match the named or numbered placeholders in the statement
+	ErrInvalidArgument = errors.New("pqxd: invalid argument")
+
+	// ErrScanNotAllowed occurs when the statement scans a whole table or index with ScanPolicyReject
+	ErrScanNotAllowed = errors.New("pqxd: scan not allowed")
+
+	// ErrNumberOutOfRange occurs when scanning a number that overflows the destination
+	ErrNumberOutOfRange = errors.New("pqxd: number out of range")
+
+	// ErrInexactNumber occurs when scanning a number with a fractional part into an integer
+	ErrInexactNumber = errors.New("pqxd: number cannot be represented exactly")
+
+	// ErrCapacityBudgetExceeded occurs when fetching a page after the capacity budget is spent
+	ErrCapacityBudgetExceeded = errors.New("pqxd: capacity budget exceeded")
+
+	// ErrAggregateRowLimitExceeded occurs when an aggregated SELECT statement reads more items than the limit
+	ErrAggregateRowLimitExceeded = errors.New("pqxd: aggregate row limit exceeded")
+
+	// ErrMixedTxStatements occurs when mixing read statements and write statements in a transaction
+	ErrMixedTxStatements = errors.New("pqxd: cannot mix read and write statements in a transaction")
 )
+
+// SyntaxError occurs when the query could not be parsed.
+//
+// SyntaxError matches ErrInvalidSyntaxOfQuery with errors.Is.
+type SyntaxError struct {
+	// Line is the line number at which the error was detected, starting at 1.
+	Line int
+
+	// Column is the column number at which the error was detected, starting at 1.
+	Column int
+
+	// Msg is the description of the error.
+	Msg string
+}
+
+// Error See: error
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("pqxd: syntax error at line %d, column %d: %s", e.Line, e.Column, e.Msg)
+}
+
+// Unwrap returns ErrInvalidSyntaxOfQuery.
+func (e *SyntaxError) Unwrap() error {
+	return ErrInvalidSyntaxOfQuery
+}
+
+// TransactionCanceledError occurs when the transaction is canceled by DynamoDB.
+//
+// The error returned from driver.Result.RowsAffected or driver.Rows of each statement in the transaction
+// is a *TransactionCanceledError with StatementIndex set to the index of the statement.
+type TransactionCanceledError struct {
+	// Message is the error message returned by DynamoDB.
+	Message string
+
+	// CancellationReasons are the reasons of cancellation, in order of statements in the transaction.
+	// The Code of the statement that did not cause the cancellation is "None".
+	CancellationReasons []CancellationReason
+
+	// StatementIndex is the index of the statement in the transaction, starting at 0.
+	// -1 if the error is not bound to a statement.
+	StatementIndex int
+
+	// err is the original error
+	err error
+}
+
+// CancellationReason is the reason why a statement in the transaction was canceled.
+type CancellationReason struct {
+	// Code is the status code of the statement. e.g. ConditionalCheckFailed, TransactionConflict or None.
+	Code string
+
+	// Message is the description of the cancellation.
+	Message string
+
+	// Item is the item which failed the condition check.
+	// nil unless requested with WithReturnValuesOnConditionCheckFailure.
+	Item map[string]types.AttributeValue
+}
+
+// Error See: error
+func (e *TransactionCanceledError) Error() string {
+	if reason, ok := e.Reason(); ok {
+		return fmt.Sprintf("pqxd: transaction canceled at statement #%d: %s: %s", e.StatementIndex, reason.Code, e.Message)
+	}
+	return fmt.Sprintf("pqxd: transaction canceled: %s", e.Message)
+}
+
+// Unwrap returns the original error returned by DynamoDB.
+func (e *TransactionCanceledError) Unwrap() error {
+	return e.err
+}
+
+// Reason returns the CancellationReason of the statement at StatementIndex.
+func (e *TransactionCanceledError) Reason() (CancellationReason, bool) {
+	if e.StatementIndex < 0 || e.StatementIndex >= len(e.CancellationReasons) {
+		return CancellationReason{}, false
+	}
+	return e.CancellationReasons[e.StatementIndex], true
+}
+
+// newTransactionCanceledError returns a new *TransactionCanceledError from *types.TransactionCanceledException.
+func newTransactionCanceledError(src *types.TransactionCanceledException) *TransactionCanceledError {
+	reasons := make([]CancellationReason, 0, len(src.CancellationReasons))
+	for _, v := range src.CancellationReasons {
+		reasons = append(
+			reasons, CancellationReason{
+				Code:    aws.ToString(v.Code),
+				Message: aws.ToString(v.Message),
+				Item:    v.Item,
+			},
+		)
+	}
+	return &TransactionCanceledError{
+		Message:             aws.ToString(src.Message),
+		CancellationReasons: reasons,
+		StatementIndex:      -1,
+		err:                 src,
+	}
+}
+
+// forStatement returns a copy of the error bound to the statement at index.
+func (e *TransactionCanceledError) forStatement(index int) *TransactionCanceledError {
+	v := *e
+	v.StatementIndex = index
+	return &v
+}
@@ -0,0 +1,90 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql/driver"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/miyamo2/pqxd/internal/partiql"
+)
+
+// explainColumns are the columns of the row returned by EXPLAIN.
+var explainColumns = []string{
+	"Statement",
+	"Table",
+	"Index",
+	"Access",
+	"KeyConditions",
+	"Projection",
+	"Transaction",
+	"Batch",
+}
+
+// explain returns the row that reports how the statement of the plan will be executed, without executing it.
+//
+// The access path is classified with the key schema of the table, described once and cached in the connector.
+func (c *connection) explain(ctx context.Context, plan *queryPlan) (driver.Rows, error) {
+	if c.closed.Load() {
+		return nil, driver.ErrBadConn
+	}
+
+	item := map[string]types.AttributeValue{
+		"Statement":     &types.AttributeValueMemberS{Value: plan.statement},
+		"Index":         &types.AttributeValueMemberNULL{Value: true},
+		"Access":        &types.AttributeValueMemberNULL{Value: true},
+		"KeyConditions": &types.AttributeValueMemberL{Value: []types.AttributeValue{}},
+		"Projection":    &types.AttributeValueMemberNULL{Value: true},
+		"Transaction": &types.AttributeValueMemberBOOL{
+			Value: plan.txStatementKind() != 0 && !plan.listTable && plan.describeTableTarget == "" && plan.aggregate == nil,
+		},
+		"Batch": &types.AttributeValueMemberBOOL{Value: plan.batchable()},
+	}
+
+	var ref *partiql.TableRef
+	switch stmt := plan.query.Statement.(type) {
+	case *partiql.SelectStatement:
+		ref = stmt.Table
+	case *partiql.InsertStatement:
+		ref = stmt.Table
+	case *partiql.UpdateStatement:
+		ref = stmt.Table
+	case *partiql.DeleteStatement:
+		ref = stmt.Table
+	case *partiql.ExistsStatement:
+		ref = stmt.Select.Table
+	}
+	if ref != nil {
+		item["Table"] = &types.AttributeValueMemberS{Value: ref.Name}
+		if ref.Index != "" {
+			item["Index"] = &types.AttributeValueMemberS{Value: ref.Index}
+		}
+	}
+	if len(plan.selectedList) != 0 {
+		item["Projection"] = toStringListAttributeValue(plan.selectedList)
+	}
+
+	if _, _, ok := plan.target(); ok {
+		table, err := c.setting.schemaCache.describe(ctx, c.client, ref.Name)
+		if err != nil {
+			return nil, err
+		}
+		path := newAccessPath(plan, table)
+		item["Access"] = &types.AttributeValueMemberS{Value: path.kind.String()}
+		item["KeyConditions"] = toStringListAttributeValue(path.keyConditions)
+		if path.kind != accessKeyLookup {
+			// transactions and batches accept only the statements on a single item specified by the full primary key.
+			item["Transaction"] = &types.AttributeValueMemberBOOL{Value: false}
+			item["Batch"] = &types.AttributeValueMemberBOOL{Value: false}
+		}
+	}
+	return newRows(explainColumns, nil, nil, []map[string]types.AttributeValue{item}), nil
+}
+
+// toStringListAttributeValue converts []string to a list of strings.
+func toStringListAttributeValue(s []string) *types.AttributeValueMemberL {
+	list := make([]types.AttributeValue, 0, len(s))
+	for _, v := range s {
+		list = append(list, &types.AttributeValueMemberS{Value: v})
+	}
+	return &types.AttributeValueMemberL{Value: list}
+}
@@ -0,0 +1,129 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql/driver"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func Test_Connection_QueryContext_with_Explain(t *testing.T) {
+	type test struct {
+		query string
+		want  []driver.Value
+	}
+
+	tests := map[string]test{
+		"select-with-key-lookup": {
+			query: `EXPLAIN SELECT id, name FROM "users" WHERE pk = ? AND sk = ? LIMIT 1`,
+			want: []driver.Value{
+				`SELECT id, name FROM "users" WHERE pk = ? AND sk = ?`,
+				"users",
+				nil,
+				"KeyLookup",
+				[]any{"pk = ?", "sk = ?"},
+				[]any{"id", "name"},
+				true,
+				false,
+			},
+		},
+		"select-aggregate-with-key-lookup": {
+			query: `EXPLAIN SELECT COUNT(*) FROM "users" WHERE pk = ? AND sk = ?`,
+			want: []driver.Value{
+				`SELECT * FROM "users" WHERE pk = ? AND sk = ?`,
+				"users",
+				nil,
+				"KeyLookup",
+				[]any{"pk = ?", "sk = ?"},
+				[]any{"COUNT(*)"},
+				false,
+				false,
+			},
+		},
+		"select-with-scan-on-index": {
+			query: `EXPLAIN SELECT * FROM "users"."gsi"`,
+			want: []driver.Value{
+				`SELECT * FROM "users"."gsi"`,
+				"users",
+				"gsi",
+				"Scan",
+				[]any{},
+				[]any{"*"},
+				false,
+				false,
+			},
+		},
+		"update-with-query": {
+			query: `EXPLAIN UPDATE "users" SET name = ? WHERE pk = ?`,
+			want: []driver.Value{
+				`UPDATE "users" SET name = ? WHERE pk = ?`,
+				"users",
+				nil,
+				"Query",
+				[]any{"pk = ?"},
+				nil,
+				false,
+				false,
+			},
+		},
+		"delete-with-key-lookup": {
+			query: `EXPLAIN DELETE FROM "users" WHERE pk = ? AND sk = ?`,
+			want: []driver.Value{
+				`DELETE FROM "users" WHERE pk = ? AND sk = ?`,
+				"users",
+				nil,
+				"KeyLookup",
+				[]any{"pk = ?", "sk = ?"},
+				nil,
+				true,
+				true,
+			},
+		},
+		"insert": {
+			query: `EXPLAIN INSERT INTO "users" VALUE {'pk': ?, 'sk': ?}`,
+			want: []driver.Value{
+				`INSERT INTO "users" VALUE {'pk': ?, 'sk': ?}`,
+				"users",
+				nil,
+				nil,
+				[]any{},
+				nil,
+				true,
+				true,
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+				WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+					ThenReturn(&dynamodb.DescribeTableOutput{Table: usersTable}, nil)
+				WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+					Verify(Never())
+
+				sut := newConnection(client)
+				rows, err := sut.QueryContext(context.Background(), tt.query, nil)
+				if err != nil {
+					t.Fatalf("QueryContext() unexpected error = %v", err)
+				}
+				defer rows.Close()
+				if diff := cmp.Diff(explainColumns, rows.Columns()); diff != "" {
+					t.Errorf("Columns() mismatch (-want +got):\n%s", diff)
+				}
+				got := make([]driver.Value, len(explainColumns))
+				if err := rows.Next(got); err != nil {
+					t.Fatalf("Next() unexpected error = %v", err)
+				}
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("Next() mismatch (-want +got):\n%s", diff)
+				}
+			},
+		)
+	}
+}
@@ -94,3 +94,12 @@ func GetAllResultSet(t *testing.T, rows driver.Rows) ([][]map[string]types.Attri
 	}
 	return results, nil
 }
+
+func InputEqual[T any](want T, opts ...cmp.Option) func() T {
+	return CreateMatcher[T](
+		"InputEqual",
+		func(allArgs []any, actual T) bool {
+			return cmp.Diff(actual, want, opts...) == ""
+		},
+	)
+}
@@ -0,0 +1,633 @@
+package partiql
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Span is a range of the source text.
+type Span struct {
+	// Start is the position of the first character.
+	Start Position
+
+	// End is the position immediately after the last character.
+	End Position
+}
+
+// Pos returns the position of the first character.
+func (s Span) Pos() Position {
+	return s.Start
+}
+
+// EndPos returns the position immediately after the last character.
+func (s Span) EndPos() Position {
+	return s.End
+}
+
+// Node is a node of the syntax tree.
+type Node interface {
+	// Pos returns the position of the first character of the node.
+	Pos() Position
+
+	// EndPos returns the position immediately after the node.
+	EndPos() Position
+}
+
+// Statement is a PartiQL statement.
+type Statement interface {
+	Node
+	statementNode()
+}
+
+// Expr is a PartiQL expression.
+type Expr interface {
+	Node
+	exprNode()
+}
+
+// SelectStatement is a SELECT statement.
+//
+//	SELECT projection FROM table[.index] [WHERE condition] [GROUP BY key, ...] [ORDER BY key [ASC|DESC], ...] [LIMIT n [OFFSET m]]
+type SelectStatement struct {
+	Span
+
+	// Projection is the list of selected items.
+	Projection *Projection
+
+	// Table is the target table.
+	Table *TableRef
+
+	// Where is the condition of the WHERE clause. nil if omitted.
+	Where Expr
+
+	// GroupBy is the GROUP BY clause. nil if omitted.
+	//
+	// Since DynamoDB does not support aggregation, the clause must be evaluated on the client side.
+	GroupBy *GroupByClause
+
+	// OrderBy is the list of ORDER BY keys.
+	OrderBy []*OrderByItem
+
+	// Limit is the LIMIT clause. nil if omitted.
+	//
+	// Since DynamoDB does not support LIMIT, the clause must be evaluated on the client side.
+	Limit *LimitClause
+}
+
+// Aggregated reports whether the statement has GROUP BY or aggregate functions in the projection.
+func (s *SelectStatement) Aggregated() bool {
+	if s.GroupBy != nil {
+		return true
+	}
+	for _, item := range s.Projection.Items {
+		if _, ok := item.Expr.(*AggregateExpr); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// GroupByClause is the GROUP BY clause of a SELECT statement.
+//
+//	GROUP BY key [, ...]
+type GroupByClause struct {
+	Span
+
+	// Keys is the list of grouping keys.
+	Keys []*Path
+}
+
+// LimitClause is the LIMIT clause of a SELECT statement.
+//
+//	LIMIT count [OFFSET offset]
+type LimitClause struct {
+	Span
+
+	// Count is the maximum number of rows.
+	Count int64
+
+	// Offset is the number of rows to skip. zero if omitted.
+	Offset int64
+}
+
+// InsertStatement is an INSERT statement.
+//
+//	INSERT INTO table VALUE item
+type InsertStatement struct {
+	Span
+
+	// Table is the target table.
+	Table *TableRef
+
+	// Value is the item to be inserted.
+	Value Expr
+}
+
+// UpdateStatement is an UPDATE statement.
+//
+//	UPDATE table SET path = value [, ...] [REMOVE path [, ...]] WHERE condition [RETURNING ...]
+type UpdateStatement struct {
+	Span
+
+	// Table is the target table.
+	Table *TableRef
+
+	// Set is the list of SET clauses in order of appearance.
+	Set []*SetClause
+
+	// Remove is the list of REMOVE clauses in order of appearance.
+	Remove []*RemoveClause
+
+	// Where is the condition of the WHERE clause.
+	Where Expr
+
+	// Returning is the RETURNING clause. nil if omitted.
+	Returning *Returning
+}
+
+// DeleteStatement is a DELETE statement.
+//
+//	DELETE FROM table WHERE condition [RETURNING ...]
+type DeleteStatement struct {
+	Span
+
+	// Table is the target table.
+	Table *TableRef
+
+	// Where is the condition of the WHERE clause.
+	Where Expr
+
+	// Returning is the RETURNING clause. nil if omitted.
+	Returning *Returning
+}
+
+// ExistsStatement is an EXISTS statement, used as a condition check in transactions.
+//
+//	EXISTS(SELECT ...)
+type ExistsStatement struct {
+	Span
+
+	// Select is the inner SELECT statement.
+	Select *SelectStatement
+}
+
+// ExplainStatement is an EXPLAIN statement, evaluated on the client side.
+//
+//	EXPLAIN SELECT|INSERT|UPDATE|DELETE|EXISTS ...
+type ExplainStatement struct {
+	Span
+
+	// Statement is the statement to be explained.
+	Statement Statement
+}
+
+// CreateTableStatement is a CREATE TABLE statement.
+//
+//	CREATE TABLE table (key type HASH|RANGE, ...) [WITH option = value, ...] [WAIT]
+type CreateTableStatement struct {
+	Span
+
+	// Table is the table to be created.
+	Table *TableRef
+
+	// Keys is the key schema of the table.
+	Keys []*KeyDefinition
+
+	// Options is the list of options of the WITH clause.
+	Options []*Option
+
+	// Wait if true, the statement blocks until the table becomes ACTIVE.
+	Wait bool
+}
+
+// DropTableStatement is a DROP TABLE statement.
+//
+//	DROP TABLE table [WAIT]
+type DropTableStatement struct {
+	Span
+
+	// Table is the table to be dropped.
+	Table *TableRef
+
+	// Wait if true, the statement blocks until the table is gone.
+	Wait bool
+}
+
+// AlterTableStatement is an ALTER TABLE statement.
+//
+//	ALTER TABLE table ADD INDEX index (key type HASH|RANGE, ...) [WITH option = value, ...] [WAIT]
+//	ALTER TABLE table DROP INDEX index [WAIT]
+type AlterTableStatement struct {
+	Span
+
+	// Table is the table to be altered.
+	Table *TableRef
+
+	// AddIndex is the index to be added. nil if not ADD INDEX.
+	AddIndex *IndexDefinition
+
+	// DropIndex is the name of the index to be dropped. Empty if not DROP INDEX.
+	DropIndex string
+
+	// Wait if true, the statement blocks until the table and its indexes become ACTIVE.
+	Wait bool
+}
+
+func (*SelectStatement) statementNode()      {}
+func (*InsertStatement) statementNode()      {}
+func (*UpdateStatement) statementNode()      {}
+func (*DeleteStatement) statementNode()      {}
+func (*ExistsStatement) statementNode()      {}
+func (*ExplainStatement) statementNode()     {}
+func (*CreateTableStatement) statementNode() {}
+func (*DropTableStatement) statementNode()   {}
+func (*AlterTableStatement) statementNode()  {}
+
+// KeyDefinition is an element of a key schema. e.g. pk S HASH
+type KeyDefinition struct {
+	Span
+
+	// Name is the attribute name.
+	Name string
+
+	// Type is the attribute type in upper case. S, N or B.
+	Type string
+
+	// KeyType is the key type in upper case. HASH or RANGE.
+	KeyType string
+}
+
+// IndexDefinition is the definition of a global secondary index.
+type IndexDefinition struct {
+	Span
+
+	// Name is the name of the index.
+	Name string
+
+	// Keys is the key schema of the index.
+	Keys []*KeyDefinition
+
+	// Options is the list of options of the WITH clause.
+	Options []*Option
+}
+
+// Option is an option of the WITH clause. e.g. BILLING_MODE=PAY_PER_REQUEST
+type Option struct {
+	Span
+
+	// Name is the option name in upper case.
+	Name string
+
+	// Value is the option value as written, unquoted if a string.
+	Value string
+}
+
+// TableRef is a reference to a table or an index of a table.
+type TableRef struct {
+	Span
+
+	// Name is the name of the table.
+	Name string
+
+	// Index is the name of the index. Empty if the table itself is referenced.
+	Index string
+}
+
+// Projection is the list of selected items of SELECT and RETURNING.
+type Projection struct {
+	Span
+
+	// Star if true, all attributes are selected.
+	Star bool
+
+	// Items is the list of selected items. Empty if Star.
+	Items []*ProjectionItem
+}
+
+// ProjectionItem is an item of Projection.
+//
+//	expr [AS alias]
+type ProjectionItem struct {
+	Span
+
+	// Expr is the selected expression.
+	Expr Expr
+
+	// Alias is the name given with AS. Empty if omitted.
+	Alias string
+}
+
+// Name returns the column name of the item. The alias if given, otherwise the path.
+func (i *ProjectionItem) Name() string {
+	if i.Alias != "" {
+		return i.Alias
+	}
+	switch v := i.Expr.(type) {
+	case *Path:
+		return v.String()
+	case *AggregateExpr:
+		return v.String()
+	}
+	return ""
+}
+
+// OrderByItem is a key of ORDER BY.
+type OrderByItem struct {
+	Span
+
+	// Expr is the sort key.
+	Expr Expr
+
+	// Desc if true, sorted in descending order.
+	Desc bool
+}
+
+// SetClause is a SET clause of UPDATE.
+type SetClause struct {
+	Span
+
+	// Path is the attribute to be set.
+	Path *Path
+
+	// Value is the value to be set.
+	Value Expr
+}
+
+// RemoveClause is a REMOVE clause of UPDATE.
+type RemoveClause struct {
+	Span
+
+	// Path is the attribute to be removed.
+	Path *Path
+}
+
+// ReturningMode is the mode of RETURNING.
+type ReturningMode string
+
+// ReturningMode values
+const (
+	ReturningAllOld      ReturningMode = "ALL OLD"
+	ReturningModifiedOld ReturningMode = "MODIFIED OLD"
+	ReturningAllNew      ReturningMode = "ALL NEW"
+	ReturningModifiedNew ReturningMode = "MODIFIED NEW"
+)
+
+// Returning is a RETURNING clause.
+type Returning struct {
+	Span
+
+	// Mode is the returning mode.
+	Mode ReturningMode
+
+	// Projection is the list of returned items.
+	Projection *Projection
+}
+
+// PathStepKind is the kind of PathStep.
+type PathStepKind int
+
+// PathStepKind values
+const (
+	// FieldStep is a map key. e.g. .city, ['city']
+	FieldStep PathStepKind = iota
+
+	// IndexStep is a list index. e.g. [0]
+	IndexStep
+)
+
+// PathStep is a step of Path after the root attribute.
+type PathStep struct {
+	Span
+
+	// Kind is the kind of the step.
+	Kind PathStepKind
+
+	// Name is the map key. Set if Kind is FieldStep.
+	Name string
+
+	// Index is the list index. Set if Kind is IndexStep.
+	Index int
+}
+
+// Path is a document path. e.g. id, address.city, tags[0]
+type Path struct {
+	Span
+
+	// Root is the name of the top-level attribute.
+	Root string
+
+	// Steps is the list of steps following Root.
+	Steps []*PathStep
+}
+
+// String returns the string representation of the Path.
+// The root attribute is written unquoted as in the column name of the result set.
+func (p *Path) String() string {
+	var sb strings.Builder
+	sb.WriteString(p.Root)
+	for _, step := range p.Steps {
+		switch step.Kind {
+		case FieldStep:
+			sb.WriteString(".")
+			sb.WriteString(step.Name)
+		case IndexStep:
+			sb.WriteString("[")
+			sb.WriteString(strconv.Itoa(step.Index))
+			sb.WriteString("]")
+		}
+	}
+	return sb.String()
+}
+
+// LiteralKind is the kind of Literal.
+type LiteralKind int
+
+// LiteralKind values
+const (
+	StringLiteral LiteralKind = iota
+	NumberLiteral
+	BoolLiteral
+	NullLiteral
+	MissingLiteral
+)
+
+// Literal is a literal value.
+type Literal struct {
+	Span
+
+	// Kind is the kind of the literal.
+	Kind LiteralKind
+
+	// Value is the unquoted value. e.g. Alice, 1.5, TRUE
+	Value string
+}
+
+// Placeholder is a parameter. e.g. ?, :name, @name, $1
+type Placeholder struct {
+	Span
+
+	// Ordinal is the position of the parameter in order of appearance, starting at 1.
+	Ordinal int
+
+	// Name is the name of a named parameter, without the prefix. e.g. name for :name and @name. empty otherwise.
+	Name string
+
+	// Number is the number of a numbered parameter. e.g. 1 for $1. zero otherwise.
+	Number int
+}
+
+// Style returns the style of the parameter. e.g. ?, :name, $1
+func (p *Placeholder) Style() string {
+	switch {
+	case p.Name != "":
+		return ":name"
+	case p.Number != 0:
+		return "$1"
+	}
+	return "?"
+}
+
+// BinaryExpr is a binary expression. e.g. a = b, a AND b
+type BinaryExpr struct {
+	Span
+
+	// Op is the operator in upper case. e.g. =, <>, AND, OR
+	Op string
+
+	// X is the left operand.
+	X Expr
+
+	// Y is the right operand.
+	Y Expr
+}
+
+// UnaryExpr is a unary expression. e.g. NOT a, -1
+type UnaryExpr struct {
+	Span
+
+	// Op is the operator in upper case. e.g. NOT, -
+	Op string
+
+	// X is the operand.
+	X Expr
+}
+
+// BetweenExpr is a BETWEEN expression.
+//
+//	x [NOT] BETWEEN lo AND hi
+type BetweenExpr struct {
+	Span
+
+	X   Expr
+	Lo  Expr
+	Hi  Expr
+	Not bool
+}
+
+// InExpr is an IN expression.
+//
+//	x [NOT] IN [a, b, ...]
+type InExpr struct {
+	Span
+
+	X    Expr
+	List []Expr
+	Not  bool
+}
+
+// IsExpr is an IS expression.
+//
+//	x IS [NOT] NULL|MISSING
+type IsExpr struct {
+	Span
+
+	X Expr
+
+	// Kind is either NullLiteral or MissingLiteral.
+	Kind LiteralKind
+
+	Not bool
+}
+
+// CallExpr is a function call. e.g. begins_with(a, 'x')
+type CallExpr struct {
+	Span
+
+	// Name is the name of the function as written.
+	Name string
+
+	// Args is the list of arguments.
+	Args []Expr
+}
+
+// AggregateExpr is an aggregate function in the projection of SELECT. e.g. COUNT(*), SUM(amount)
+//
+// Since DynamoDB does not support aggregation, the function must be evaluated on the client side.
+type AggregateExpr struct {
+	Span
+
+	// Func is the function name in upper case. COUNT, SUM, MIN, MAX or AVG.
+	Func string
+
+	// Arg is the aggregated attribute. nil for COUNT(*).
+	Arg *Path
+}
+
+// String returns the string representation of the AggregateExpr. e.g. COUNT(*), SUM(amount)
+func (a *AggregateExpr) String() string {
+	if a.Arg == nil {
+		return a.Func + "(*)"
+	}
+	return a.Func + "(" + a.Arg.String() + ")"
+}
+
+// TupleField is a field of TupleExpr.
+type TupleField struct {
+	Span
+
+	Key   Expr
+	Value Expr
+}
+
+// TupleExpr is a tuple constructor. e.g. {'id': ?, 'name': 'Alice'}
+type TupleExpr struct {
+	Span
+
+	Fields []*TupleField
+}
+
+// ListExpr is a list constructor. e.g. [1, 2]
+type ListExpr struct {
+	Span
+
+	Elems []Expr
+}
+
+// BagExpr is a bag constructor, used for sets. e.g. <<'a', 'b'>>
+type BagExpr struct {
+	Span
+
+	Elems []Expr
+}
+
+// ParenExpr is a parenthesized expression.
+type ParenExpr struct {
+	Span
+
+	X Expr
+}
+
+func (*Path) exprNode()          {}
+func (*Literal) exprNode()       {}
+func (*Placeholder) exprNode()   {}
+func (*BinaryExpr) exprNode()    {}
+func (*UnaryExpr) exprNode()     {}
+func (*BetweenExpr) exprNode()   {}
+func (*InExpr) exprNode()        {}
+func (*IsExpr) exprNode()        {}
+func (*CallExpr) exprNode()      {}
+func (*AggregateExpr) exprNode() {}
+func (*TupleExpr) exprNode()     {}
+func (*ListExpr) exprNode()      {}
+func (*BagExpr) exprNode()       {}
+func (*ParenExpr) exprNode()     {}
@@ -0,0 +1,22 @@
+package partiql
+
+import "fmt"
+
+// Error is a syntax error detected by the lexer or the parser.
+type Error struct {
+	// Pos is the position at which the error was detected.
+	Pos Position
+
+	// Msg is the description of the error.
+	Msg string
+}
+
+// Error See: error
+func (e *Error) Error() string {
+	return fmt.Sprintf("line %d, column %d: %s", e.Pos.Line, e.Pos.Column, e.Msg)
+}
+
+// errorf returns a new Error at the given position.
+func errorf(pos Position, format string, args ...any) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
@@ -0,0 +1,306 @@
+package partiql
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// lexer splits the source text into tokens.
+type lexer struct {
+	// src is the source text.
+	src string
+
+	// pos is the current position.
+	pos Position
+
+	// comments is the list of comments that have been skipped.
+	comments []Span
+
+	// prev is the previous token.
+	prev Token
+}
+
+// Lex splits src into tokens. The returned list always ends with an EOF token.
+// Line comments (-- ...) and block comments (/* ... */) are skipped and reported separately.
+func Lex(src string) (tokens []Token, comments []Span, err error) {
+	l := &lexer{src: src, pos: Position{Line: 1, Column: 1}}
+	for {
+		tok, err := l.next()
+		if err != nil {
+			return nil, nil, err
+		}
+		tokens = append(tokens, tok)
+		l.prev = tok
+		if tok.Kind == EOF {
+			return tokens, l.comments, nil
+		}
+	}
+}
+
+// peek returns the rune at the current position plus n bytes, or utf8.RuneError at the end of the source.
+func (l *lexer) peek(n int) rune {
+	if l.pos.Offset+n >= len(l.src) {
+		return utf8.RuneError
+	}
+	r, _ := utf8.DecodeRuneInString(l.src[l.pos.Offset+n:])
+	return r
+}
+
+// advance moves the current position forward by one rune.
+func (l *lexer) advance() {
+	r, size := utf8.DecodeRuneInString(l.src[l.pos.Offset:])
+	l.pos.Offset += size
+	if r == '\n' {
+		l.pos.Line++
+		l.pos.Column = 1
+		return
+	}
+	l.pos.Column++
+}
+
+// eof reports whether the current position is at the end of the source.
+func (l *lexer) eof() bool {
+	return l.pos.Offset >= len(l.src)
+}
+
+// skipSpacesAndComments skips whitespace and comments.
+func (l *lexer) skipSpacesAndComments() error {
+	for !l.eof() {
+		r := l.peek(0)
+		switch {
+		case unicode.IsSpace(r):
+			l.advance()
+		case r == '-' && l.peek(1) == '-':
+			start := l.pos
+			for !l.eof() && l.peek(0) != '\n' {
+				l.advance()
+			}
+			l.comments = append(l.comments, Span{Start: start, End: l.pos})
+		case r == '/' && l.peek(1) == '*':
+			start := l.pos
+			l.advance()
+			l.advance()
+			for {
+				if l.eof() {
+					return errorf(start, "unterminated block comment")
+				}
+				if l.peek(0) == '*' && l.peek(1) == '/' {
+					l.advance()
+					l.advance()
+					break
+				}
+				l.advance()
+			}
+			l.comments = append(l.comments, Span{Start: start, End: l.pos})
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// punctuations is the list of punctuations, longest first.
+var punctuations = []struct {
+	text string
+	kind Kind
+}{
+	{"<<", LBag},
+	{">>", RBag},
+	{"<>", Neq},
+	{"!=", Neq},
+	{"<=", Le},
+	{">=", Ge},
+	{"||", Concat},
+	{"(", LParen},
+	{")", RParen},
+	{"[", LBracket},
+	{"]", RBracket},
+	{"{", LBrace},
+	{"}", RBrace},
+	{",", Comma},
+	{".", Dot},
+	{":", Colon},
+	{";", Semicolon},
+	{"*", Star},
+	{"=", Eq},
+	{"<", Lt},
+	{">", Gt},
+	{"+", Plus},
+	{"-", Minus},
+	{"/", Slash},
+	{"%", Percent},
+	{"?", Param},
+}
+
+// next returns the next token.
+func (l *lexer) next() (Token, error) {
+	if err := l.skipSpacesAndComments(); err != nil {
+		return Token{}, err
+	}
+	start := l.pos
+	if l.eof() {
+		return Token{Kind: EOF, Pos: start, End: start}, nil
+	}
+
+	r := l.peek(0)
+	switch {
+	case r == '\'':
+		value, err := l.quoted('\'')
+		if err != nil {
+			return Token{}, err
+		}
+		return l.quotedToken(String, start, value), nil
+	case r == '"':
+		value, err := l.quoted('"')
+		if err != nil {
+			return Token{}, err
+		}
+		if value == "" {
+			return Token{}, errorf(start, "empty quoted identifier")
+		}
+		return l.quotedToken(QuotedIdent, start, value), nil
+	case isDigit(r) || (r == '.' && isDigit(l.peek(1))):
+		if err := l.number(); err != nil {
+			return Token{}, err
+		}
+		return l.token(Number, start), nil
+	case isIdentStart(r):
+		for !l.eof() && isIdentPart(l.peek(0)) {
+			l.advance()
+		}
+		return l.token(Ident, start), nil
+	case r == '@' && isIdentStart(l.peek(1)), r == ':' && isIdentStart(l.peek(1)) && !l.afterOperand():
+		l.advance()
+		for !l.eof() && isIdentPart(l.peek(0)) {
+			l.advance()
+		}
+		return l.paramToken(start), nil
+	case r == '$' && isDigit(l.peek(1)):
+		l.advance()
+		for isDigit(l.peek(0)) {
+			l.advance()
+		}
+		if isIdentPart(l.peek(0)) {
+			return Token{}, errorf(l.pos, "unexpected character %q in parameter", l.peek(0))
+		}
+		return l.paramToken(start), nil
+	}
+
+	for _, p := range punctuations {
+		if strings.HasPrefix(l.src[l.pos.Offset:], p.text) {
+			for range p.text {
+				l.advance()
+			}
+			return l.token(p.kind, start), nil
+		}
+	}
+	return Token{}, errorf(start, "unexpected character %q", r)
+}
+
+// token returns a token from start to the current position.
+func (l *lexer) token(kind Kind, start Position) Token {
+	text := l.src[start.Offset:l.pos.Offset]
+	return Token{Kind: kind, Text: text, Value: text, Pos: start, End: l.pos}
+}
+
+// paramToken returns a Param token of a named or numbered parameter, whose value is the name or number without the prefix.
+func (l *lexer) paramToken(start Position) Token {
+	tok := l.token(Param, start)
+	tok.Value = tok.Text[1:]
+	return tok
+}
+
+// afterOperand reports whether the previous token ends an operand, so that a following colon separates a tuple key
+// and its value, as in {'a':b}, rather than starting a named parameter.
+func (l *lexer) afterOperand() bool {
+	switch l.prev.Kind {
+	case QuotedIdent, String, Number, Param, RParen, RBracket, RBrace, RBag:
+		return true
+	case Ident:
+		_, reserved := reservedWords[strings.ToUpper(l.prev.Text)]
+		return !reserved
+	}
+	return false
+}
+
+// quotedToken returns a token from start to the current position with the unquoted value.
+func (l *lexer) quotedToken(kind Kind, start Position, value string) Token {
+	tok := l.token(kind, start)
+	tok.Value = value
+	return tok
+}
+
+// quoted reads a quoted string or identifier. Doubled quotes are unescaped.
+func (l *lexer) quoted(quote rune) (string, error) {
+	start := l.pos
+	l.advance()
+	var sb strings.Builder
+	for {
+		if l.eof() {
+			if quote == '"' {
+				return "", errorf(start, "unterminated quoted identifier")
+			}
+			return "", errorf(start, "unterminated string")
+		}
+		r := l.peek(0)
+		l.advance()
+		if r != quote {
+			sb.WriteRune(r)
+			continue
+		}
+		if l.peek(0) == quote {
+			sb.WriteRune(r)
+			l.advance()
+			continue
+		}
+		return sb.String(), nil
+	}
+}
+
+// number reads a numeric literal.
+func (l *lexer) number() error {
+	for isDigit(l.peek(0)) {
+		l.advance()
+	}
+	if l.peek(0) == '.' && isDigit(l.peek(1)) {
+		l.advance()
+		for isDigit(l.peek(0)) {
+			l.advance()
+		}
+	}
+	if r := l.peek(0); r == 'e' || r == 'E' {
+		n := 1
+		if s := l.peek(1); s == '+' || s == '-' {
+			n = 2
+		}
+		if !isDigit(l.peek(n)) {
+			return errorf(l.pos, "malformed number exponent")
+		}
+		for range n {
+			l.advance()
+		}
+		for isDigit(l.peek(0)) {
+			l.advance()
+		}
+	}
+	if isIdentPart(l.peek(0)) {
+		return errorf(l.pos, "unexpected character %q in number", l.peek(0))
+	}
+	return nil
+}
+
+// isDigit reports whether r is an ASCII digit.
+func isDigit(r rune) bool {
+	return '0' <= r && r <= '9'
+}
+
+// isIdentStart reports whether r can start an unquoted identifier.
+func isIdentStart(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// isIdentPart reports whether r can continue an unquoted identifier.
+func isIdentPart(r rune) bool {
+	return isIdentStart(r) || isDigit(r)
+}
@@ -0,0 +1,1235 @@
+package partiql
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// reservedWords is the list of keywords that cannot be used as unquoted attribute names.
+var reservedWords = map[string]struct{}{
+	"AND":       {},
+	"BETWEEN":   {},
+	"BY":        {},
+	"DELETE":    {},
+	"EXISTS":    {},
+	"FROM":      {},
+	"IN":        {},
+	"INSERT":    {},
+	"INTO":      {},
+	"IS":        {},
+	"NOT":       {},
+	"OR":        {},
+	"ORDER":     {},
+	"REMOVE":    {},
+	"RETURNING": {},
+	"SELECT":    {},
+	"SET":       {},
+	"UPDATE":    {},
+	"VALUE":     {},
+	"WHERE":     {},
+}
+
+// aggregateFunctions is the list of aggregate functions allowed in the projection of SELECT.
+var aggregateFunctions = map[string]struct{}{
+	"COUNT": {},
+	"SUM":   {},
+	"MIN":   {},
+	"MAX":   {},
+	"AVG":   {},
+}
+
+// parser is a recursive descent parser for PartiQL statements.
+type parser struct {
+	// tokens is the list of tokens to be parsed.
+	tokens []Token
+
+	// cursor is the index of the current token.
+	cursor int
+
+	// placeholders is the list of placeholders that have been parsed.
+	placeholders []*Placeholder
+}
+
+// Parse parses a single PartiQL statement.
+// The returned error is an *Error that reports the position of the syntax error.
+func Parse(src string) (*Query, error) {
+	tokens, comments, err := Lex(src)
+	if err != nil {
+		return nil, err
+	}
+	p := &parser{tokens: tokens}
+
+	stmt, err := p.parseStatement()
+	if err != nil {
+		return nil, err
+	}
+
+	elided := comments
+	if tok := p.peek(); tok.Kind == Semicolon {
+		elided = append(elided, Span{Start: tok.Pos, End: tok.End})
+		p.next()
+	}
+	if tok := p.peek(); tok.Kind != EOF {
+		return nil, p.unexpected(tok, "end of statement")
+	}
+	return &Query{
+		Source:       src,
+		Statement:    stmt,
+		Placeholders: p.placeholders,
+		Hints:        hintsFromComments(src, comments),
+		elided:       elided,
+	}, nil
+}
+
+// peek returns the current token.
+func (p *parser) peek() Token {
+	return p.tokens[p.cursor]
+}
+
+// peekN returns the token n positions ahead of the current token.
+func (p *parser) peekN(n int) Token {
+	if p.cursor+n >= len(p.tokens) {
+		return p.tokens[len(p.tokens)-1]
+	}
+	return p.tokens[p.cursor+n]
+}
+
+// next consumes and returns the current token.
+func (p *parser) next() Token {
+	tok := p.tokens[p.cursor]
+	if tok.Kind != EOF {
+		p.cursor++
+	}
+	return tok
+}
+
+// prevEnd returns the end position of the last consumed token.
+func (p *parser) prevEnd() Position {
+	if p.cursor == 0 {
+		return p.tokens[0].Pos
+	}
+	return p.tokens[p.cursor-1].End
+}
+
+// spanFrom returns the span from start to the end of the last consumed token.
+func (p *parser) spanFrom(start Position) Span {
+	return Span{Start: start, End: p.prevEnd()}
+}
+
+// unexpected returns an error for an unexpected token.
+func (p *parser) unexpected(tok Token, expected string) *Error {
+	return errorf(tok.Pos, "unexpected %s, expected %s", tok, expected)
+}
+
+// expect consumes the current token if it is of the given kind.
+func (p *parser) expect(kind Kind) (Token, error) {
+	tok := p.peek()
+	if tok.Kind != kind {
+		return tok, p.unexpected(tok, kind.String())
+	}
+	return p.next(), nil
+}
+
+// acceptKeyword consumes the current token if it is the given keyword.
+func (p *parser) acceptKeyword(keyword string) bool {
+	if p.peek().IsKeyword(keyword) {
+		p.next()
+		return true
+	}
+	return false
+}
+
+// expectKeyword consumes the current token if it is the given keyword.
+func (p *parser) expectKeyword(keyword string) (Token, error) {
+	tok := p.peek()
+	if !tok.IsKeyword(keyword) {
+		return tok, p.unexpected(tok, keyword)
+	}
+	return p.next(), nil
+}
+
+// parseStatement parses a statement.
+func (p *parser) parseStatement() (Statement, error) {
+	tok := p.peek()
+	switch {
+	case tok.IsKeyword("SELECT"):
+		return p.parseSelect()
+	case tok.IsKeyword("INSERT"):
+		return p.parseInsert()
+	case tok.IsKeyword("UPDATE"):
+		return p.parseUpdate()
+	case tok.IsKeyword("DELETE"):
+		return p.parseDelete()
+	case tok.IsKeyword("EXISTS"):
+		return p.parseExists()
+	case tok.IsKeyword("EXPLAIN"):
+		return p.parseExplain()
+	case tok.IsKeyword("CREATE"):
+		return p.parseCreateTable()
+	case tok.IsKeyword("DROP"):
+		return p.parseDropTable()
+	case tok.IsKeyword("ALTER"):
+		return p.parseAlterTable()
+	}
+	return nil, p.unexpected(tok, "SELECT, INSERT, UPDATE, DELETE, EXISTS, EXPLAIN, CREATE, DROP or ALTER")
+}
+
+// parseSelect parses a SELECT statement.
+func (p *parser) parseSelect() (*SelectStatement, error) {
+	start, err := p.expectKeyword("SELECT")
+	if err != nil {
+		return nil, err
+	}
+	stmt := &SelectStatement{}
+	if stmt.Projection, err = p.parseProjection(true); err != nil {
+		return nil, err
+	}
+	if _, err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	if stmt.Table, err = p.parseTableRef(true); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("WHERE") {
+		if stmt.Where, err = p.parseExpr(); err != nil {
+			return nil, err
+		}
+	}
+	if stmt.GroupBy, err = p.parseGroupBy(); err != nil {
+		return nil, err
+	}
+	if err := checkGrouping(stmt); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("ORDER") {
+		if _, err := p.expectKeyword("BY"); err != nil {
+			return nil, err
+		}
+		for {
+			item, err := p.parseOrderByItem()
+			if err != nil {
+				return nil, err
+			}
+			stmt.OrderBy = append(stmt.OrderBy, item)
+			if p.peek().Kind != Comma {
+				break
+			}
+			p.next()
+		}
+	}
+	if stmt.Limit, err = p.parseLimit(); err != nil {
+		return nil, err
+	}
+	stmt.Span = p.spanFrom(start.Pos)
+	return stmt, nil
+}
+
+// parseGroupBy parses the GROUP BY clause if present.
+func (p *parser) parseGroupBy() (*GroupByClause, error) {
+	start := p.peek().Pos
+	if !p.acceptKeyword("GROUP") {
+		return nil, nil
+	}
+	if _, err := p.expectKeyword("BY"); err != nil {
+		return nil, err
+	}
+	groupBy := &GroupByClause{}
+	for {
+		key, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		groupBy.Keys = append(groupBy.Keys, key)
+		if p.peek().Kind != Comma {
+			break
+		}
+		p.next()
+	}
+	groupBy.Span = p.spanFrom(start)
+	return groupBy, nil
+}
+
+// checkGrouping checks that every selected item of the aggregated statement is a grouping key or an aggregate function.
+func checkGrouping(stmt *SelectStatement) error {
+	if !stmt.Aggregated() {
+		return nil
+	}
+	if stmt.Projection.Star {
+		return errorf(stmt.Projection.Pos(), "* cannot be selected with GROUP BY or aggregate functions")
+	}
+	for _, item := range stmt.Projection.Items {
+		path, ok := item.Expr.(*Path)
+		if !ok {
+			continue
+		}
+		grouped := stmt.GroupBy != nil && slices.ContainsFunc(
+			stmt.GroupBy.Keys, func(key *Path) bool {
+				return key.String() == path.String()
+			},
+		)
+		if !grouped {
+			return errorf(path.Pos(), "%s must appear in GROUP BY or be used in an aggregate function", path)
+		}
+	}
+	return nil
+}
+
+// parseLimit parses the LIMIT clause if present.
+func (p *parser) parseLimit() (*LimitClause, error) {
+	start := p.peek().Pos
+	if !p.acceptKeyword("LIMIT") {
+		return nil, nil
+	}
+	limit := &LimitClause{}
+	var err error
+	if limit.Count, err = p.parseNonNegativeInteger(); err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("OFFSET") {
+		if limit.Offset, err = p.parseNonNegativeInteger(); err != nil {
+			return nil, err
+		}
+	}
+	limit.Span = p.spanFrom(start)
+	return limit, nil
+}
+
+// parseNonNegativeInteger parses an integer literal greater than or equal to zero.
+func (p *parser) parseNonNegativeInteger() (int64, error) {
+	tok := p.next()
+	if tok.Kind != Number {
+		return 0, p.unexpected(tok, "non-negative integer")
+	}
+	v, err := strconv.ParseInt(tok.Text, 10, 64)
+	if err != nil || v < 0 {
+		return 0, p.unexpected(tok, "non-negative integer")
+	}
+	return v, nil
+}
+
+// parseOrderByItem parses a key of ORDER BY.
+func (p *parser) parseOrderByItem() (*OrderByItem, error) {
+	start := p.peek().Pos
+	expr, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	item := &OrderByItem{Expr: expr}
+	switch {
+	case p.acceptKeyword("ASC"):
+	case p.acceptKeyword("DESC"):
+		item.Desc = true
+	}
+	item.Span = p.spanFrom(start)
+	return item, nil
+}
+
+// parseProjection parses the list of selected items. Aggregate functions are parsed if allowAggregate.
+func (p *parser) parseProjection(allowAggregate bool) (*Projection, error) {
+	start := p.peek().Pos
+	if p.peek().Kind == Star {
+		p.next()
+		return &Projection{Span: p.spanFrom(start), Star: true}, nil
+	}
+	projection := &Projection{}
+	for {
+		item, err := p.parseProjectionItem(allowAggregate)
+		if err != nil {
+			return nil, err
+		}
+		projection.Items = append(projection.Items, item)
+		if p.peek().Kind != Comma {
+			break
+		}
+		p.next()
+	}
+	projection.Span = p.spanFrom(start)
+	return projection, nil
+}
+
+// parseProjectionItem parses an item of the projection, optionally followed by AS alias.
+func (p *parser) parseProjectionItem(allowAggregate bool) (*ProjectionItem, error) {
+	start := p.peek().Pos
+	item := &ProjectionItem{}
+	var err error
+	if tok := p.peek(); allowAggregate && tok.Kind == Ident && p.peekN(1).Kind == LParen && isAggregate(tok) {
+		item.Expr, err = p.parseAggregate()
+	} else {
+		item.Expr, err = p.parsePath()
+	}
+	if err != nil {
+		return nil, err
+	}
+	if p.acceptKeyword("AS") {
+		if item.Alias, err = p.parseName("alias"); err != nil {
+			return nil, err
+		}
+	}
+	item.Span = p.spanFrom(start)
+	return item, nil
+}
+
+// parseAggregate parses an aggregate function. Only COUNT accepts *.
+func (p *parser) parseAggregate() (*AggregateExpr, error) {
+	name := p.next()
+	if _, err := p.expect(LParen); err != nil {
+		return nil, err
+	}
+	agg := &AggregateExpr{Func: strings.ToUpper(name.Text)}
+	if tok := p.peek(); tok.Kind == Star && agg.Func == "COUNT" {
+		p.next()
+	} else {
+		arg, err := p.parsePath()
+		if err != nil {
+			return nil, err
+		}
+		agg.Arg = arg
+	}
+	if _, err := p.expect(RParen); err != nil {
+		return nil, err
+	}
+	agg.Span = p.spanFrom(name.Pos)
+	return agg, nil
+}
+
+// parseTableRef parses a table name, optionally followed by an index name.
+func (p *parser) parseTableRef(allowIndex bool) (*TableRef, error) {
+	start := p.peek().Pos
+	name, err := p.parseName("table name")
+	if err != nil {
+		return nil, err
+	}
+	ref := &TableRef{Name: name}
+	if allowIndex && p.peek().Kind == Dot {
+		p.next()
+		if ref.Index, err = p.parseName("index name"); err != nil {
+			return nil, err
+		}
+	}
+	ref.Span = p.spanFrom(start)
+	return ref, nil
+}
+
+// parseName parses a quoted or unquoted identifier.
+func (p *parser) parseName(expected string) (string, error) {
+	tok := p.peek()
+	switch {
+	case tok.Kind == QuotedIdent:
+		p.next()
+		return tok.Value, nil
+	case tok.Kind == Ident && !isReserved(tok):
+		p.next()
+		return tok.Value, nil
+	}
+	return "", p.unexpected(tok, expected)
+}
+
+// parseInsert parses an INSERT statement.
+func (p *parser) parseInsert() (*InsertStatement, error) {
+	start, err := p.expectKeyword("INSERT")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expectKeyword("INTO"); err != nil {
+		return nil, err
+	}
+	stmt := &InsertStatement{}
+	if stmt.Table, err = p.parseTableRef(false); err != nil {
+		return nil, err
+	}
+	if _, err := p.expectKeyword("VALUE"); err != nil {
+		return nil, err
+	}
+	if stmt.Value, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	stmt.Span = p.spanFrom(start.Pos)
+	return stmt, nil
+}
+
+// parseUpdate parses an UPDATE statement.
+func (p *parser) parseUpdate() (*UpdateStatement, error) {
+	start, err := p.expectKeyword("UPDATE")
+	if err != nil {
+		return nil, err
+	}
+	stmt := &UpdateStatement{}
+	if stmt.Table, err = p.parseTableRef(false); err != nil {
+		return nil, err
+	}
+	for {
+		tok := p.peek()
+		if tok.IsKeyword("SET") {
+			p.next()
+			if err := p.parseSetClauses(stmt); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if tok.IsKeyword("REMOVE") {
+			p.next()
+			if err := p.parseRemoveClauses(stmt); err != nil {
+				return nil, err
+			}
+			continue
+		}
+		if len(stmt.Set) == 0 && len(stmt.Remove) == 0 {
+			return nil, p.unexpected(tok, "SET or REMOVE")
+		}
+		break
+	}
+	if _, err := p.expectKeyword("WHERE"); err != nil {
+		return nil, err
+	}
+	if stmt.Where, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	if stmt.Returning, err = p.parseReturning(); err != nil {
+		return nil, err
+	}
+	stmt.Span = p.spanFrom(start.Pos)
+	return stmt, nil
+}
+
+// parseSetClauses parses a comma-separated list of assignments following SET.
+func (p *parser) parseSetClauses(stmt *UpdateStatement) error {
+	for {
+		start := p.peek().Pos
+		path, err := p.parsePath()
+		if err != nil {
+			return err
+		}
+		if _, err := p.expect(Eq); err != nil {
+			return err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return err
+		}
+		stmt.Set = append(stmt.Set, &SetClause{Span: p.spanFrom(start), Path: path, Value: value})
+		if p.peek().Kind != Comma {
+			return nil
+		}
+		p.next()
+	}
+}
+
+// parseRemoveClauses parses a comma-separated list of paths following REMOVE.
+func (p *parser) parseRemoveClauses(stmt *UpdateStatement) error {
+	for {
+		start := p.peek().Pos
+		path, err := p.parsePath()
+		if err != nil {
+			return err
+		}
+		stmt.Remove = append(stmt.Remove, &RemoveClause{Span: p.spanFrom(start), Path: path})
+		if p.peek().Kind != Comma {
+			return nil
+		}
+		p.next()
+	}
+}
+
+// parseDelete parses a DELETE statement.
+func (p *parser) parseDelete() (*DeleteStatement, error) {
+	start, err := p.expectKeyword("DELETE")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expectKeyword("FROM"); err != nil {
+		return nil, err
+	}
+	stmt := &DeleteStatement{}
+	if stmt.Table, err = p.parseTableRef(false); err != nil {
+		return nil, err
+	}
+	if _, err := p.expectKeyword("WHERE"); err != nil {
+		return nil, err
+	}
+	if stmt.Where, err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	if stmt.Returning, err = p.parseReturning(); err != nil {
+		return nil, err
+	}
+	stmt.Span = p.spanFrom(start.Pos)
+	return stmt, nil
+}
+
+// parseExists parses an EXISTS statement.
+func (p *parser) parseExists() (*ExistsStatement, error) {
+	start, err := p.expectKeyword("EXISTS")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(LParen); err != nil {
+		return nil, err
+	}
+	sel, err := p.parseSelect()
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expect(RParen); err != nil {
+		return nil, err
+	}
+	return &ExistsStatement{Span: p.spanFrom(start.Pos), Select: sel}, nil
+}
+
+// parseExplain parses an EXPLAIN statement.
+func (p *parser) parseExplain() (*ExplainStatement, error) {
+	start, err := p.expectKeyword("EXPLAIN")
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	for _, v := range []string{"SELECT", "INSERT", "UPDATE", "DELETE", "EXISTS"} {
+		if !tok.IsKeyword(v) {
+			continue
+		}
+		stmt, err := p.parseStatement()
+		if err != nil {
+			return nil, err
+		}
+		return &ExplainStatement{Span: p.spanFrom(start.Pos), Statement: stmt}, nil
+	}
+	return nil, p.unexpected(tok, "SELECT, INSERT, UPDATE, DELETE or EXISTS")
+}
+
+// parseCreateTable parses a CREATE TABLE statement.
+func (p *parser) parseCreateTable() (*CreateTableStatement, error) {
+	start, err := p.expectKeyword("CREATE")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expectKeyword("TABLE"); err != nil {
+		return nil, err
+	}
+	stmt := &CreateTableStatement{}
+	if stmt.Table, err = p.parseTableRef(false); err != nil {
+		return nil, err
+	}
+	if stmt.Keys, err = p.parseKeySchema(); err != nil {
+		return nil, err
+	}
+	if stmt.Options, err = p.parseOptions(); err != nil {
+		return nil, err
+	}
+	stmt.Wait = p.acceptKeyword("WAIT")
+	stmt.Span = p.spanFrom(start.Pos)
+	return stmt, nil
+}
+
+// parseDropTable parses a DROP TABLE statement.
+func (p *parser) parseDropTable() (*DropTableStatement, error) {
+	start, err := p.expectKeyword("DROP")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expectKeyword("TABLE"); err != nil {
+		return nil, err
+	}
+	stmt := &DropTableStatement{}
+	if stmt.Table, err = p.parseTableRef(false); err != nil {
+		return nil, err
+	}
+	stmt.Wait = p.acceptKeyword("WAIT")
+	stmt.Span = p.spanFrom(start.Pos)
+	return stmt, nil
+}
+
+// parseAlterTable parses an ALTER TABLE statement.
+func (p *parser) parseAlterTable() (*AlterTableStatement, error) {
+	start, err := p.expectKeyword("ALTER")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := p.expectKeyword("TABLE"); err != nil {
+		return nil, err
+	}
+	stmt := &AlterTableStatement{}
+	if stmt.Table, err = p.parseTableRef(false); err != nil {
+		return nil, err
+	}
+	switch tok := p.next(); {
+	case tok.IsKeyword("ADD"):
+		if _, err := p.expectKeyword("INDEX"); err != nil {
+			return nil, err
+		}
+		index := &IndexDefinition{}
+		if index.Name, err = p.parseName("index name"); err != nil {
+			return nil, err
+		}
+		if index.Keys, err = p.parseKeySchema(); err != nil {
+			return nil, err
+		}
+		if index.Options, err = p.parseOptions(); err != nil {
+			return nil, err
+		}
+		index.Span = p.spanFrom(tok.Pos)
+		stmt.AddIndex = index
+	case tok.IsKeyword("DROP"):
+		if _, err := p.expectKeyword("INDEX"); err != nil {
+			return nil, err
+		}
+		if stmt.DropIndex, err = p.parseName("index name"); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, p.unexpected(tok, "ADD or DROP")
+	}
+	stmt.Wait = p.acceptKeyword("WAIT")
+	stmt.Span = p.spanFrom(start.Pos)
+	return stmt, nil
+}
+
+// parseKeySchema parses a parenthesized key schema. e.g. (pk S HASH, sk N RANGE)
+func (p *parser) parseKeySchema() ([]*KeyDefinition, error) {
+	start, err := p.expect(LParen)
+	if err != nil {
+		return nil, err
+	}
+	var (
+		keys     []*KeyDefinition
+		hasHash  bool
+		hasRange bool
+	)
+	for {
+		key, err := p.parseKeyDefinition()
+		if err != nil {
+			return nil, err
+		}
+		switch {
+		case key.KeyType == "HASH" && hasHash, key.KeyType == "RANGE" && hasRange:
+			return nil, errorf(key.Pos(), "duplicate %s key", key.KeyType)
+		case key.KeyType == "HASH":
+			hasHash = true
+		case key.KeyType == "RANGE":
+			hasRange = true
+		}
+		keys = append(keys, key)
+
+		tok := p.next()
+		if tok.Kind == RParen {
+			break
+		}
+		if tok.Kind != Comma {
+			return nil, p.unexpected(tok, ", or )")
+		}
+	}
+	if !hasHash {
+		return nil, errorf(start.Pos, "key schema requires a HASH key")
+	}
+	return keys, nil
+}
+
+// parseKeyDefinition parses an element of a key schema.
+func (p *parser) parseKeyDefinition() (*KeyDefinition, error) {
+	start := p.peek().Pos
+	name, err := p.parseName("attribute name")
+	if err != nil {
+		return nil, err
+	}
+	key := &KeyDefinition{Name: name}
+	switch tok := p.next(); {
+	case tok.IsKeyword("S"), tok.IsKeyword("N"), tok.IsKeyword("B"):
+		key.Type = strings.ToUpper(tok.Text)
+	default:
+		return nil, p.unexpected(tok, "attribute type S, N or B")
+	}
+	switch tok := p.next(); {
+	case tok.IsKeyword("HASH"), tok.IsKeyword("RANGE"):
+		key.KeyType = strings.ToUpper(tok.Text)
+	default:
+		return nil, p.unexpected(tok, "HASH or RANGE")
+	}
+	key.Span = p.spanFrom(start)
+	return key, nil
+}
+
+// parseOptions parses the WITH clause if present.
+func (p *parser) parseOptions() ([]*Option, error) {
+	if !p.acceptKeyword("WITH") {
+		return nil, nil
+	}
+	var options []*Option
+	for {
+		name := p.next()
+		if name.Kind != Ident {
+			return nil, p.unexpected(name, "option name")
+		}
+		if _, err := p.expect(Eq); err != nil {
+			return nil, err
+		}
+		value := p.next()
+		switch value.Kind {
+		case Ident, Number, String:
+		default:
+			return nil, p.unexpected(value, "option value")
+		}
+		options = append(
+			options, &Option{Span: p.spanFrom(name.Pos), Name: strings.ToUpper(name.Text), Value: value.Value},
+		)
+		if p.peek().Kind != Comma {
+			return options, nil
+		}
+		p.next()
+	}
+}
+
+// parseReturning parses the RETURNING clause if present.
+func (p *parser) parseReturning() (*Returning, error) {
+	start := p.peek().Pos
+	if !p.acceptKeyword("RETURNING") {
+		return nil, nil
+	}
+	var scope, version string
+	switch tok := p.next(); {
+	case tok.IsKeyword("ALL"), tok.IsKeyword("MODIFIED"):
+		scope = strings.ToUpper(tok.Text)
+	default:
+		return nil, p.unexpected(tok, "ALL or MODIFIED")
+	}
+	switch tok := p.next(); {
+	case tok.IsKeyword("OLD"), tok.IsKeyword("NEW"):
+		version = strings.ToUpper(tok.Text)
+	default:
+		return nil, p.unexpected(tok, "OLD or NEW")
+	}
+	projection, err := p.parseProjection(false)
+	if err != nil {
+		return nil, err
+	}
+	return &Returning{
+		Span:       p.spanFrom(start),
+		Mode:       ReturningMode(scope + " " + version),
+		Projection: projection,
+	}, nil
+}
+
+// parsePath parses a document path.
+func (p *parser) parsePath() (*Path, error) {
+	start := p.peek().Pos
+	root, err := p.parseName("attribute name")
+	if err != nil {
+		return nil, err
+	}
+	path := &Path{Root: root}
+	for {
+		stepStart := p.peek().Pos
+		switch p.peek().Kind {
+		case Dot:
+			p.next()
+			tok := p.next()
+			if tok.Kind != Ident && tok.Kind != QuotedIdent {
+				return nil, p.unexpected(tok, "attribute name")
+			}
+			path.Steps = append(
+				path.Steps, &PathStep{Span: p.spanFrom(stepStart), Kind: FieldStep, Name: tok.Value},
+			)
+			continue
+		case LBracket:
+			p.next()
+			step := &PathStep{}
+			switch tok := p.next(); tok.Kind {
+			case Number:
+				idx, err := strconv.Atoi(tok.Text)
+				if err != nil || idx < 0 {
+					return nil, errorf(tok.Pos, "invalid list index %s", tok.Text)
+				}
+				step.Kind = IndexStep
+				step.Index = idx
+			case String:
+				step.Kind = FieldStep
+				step.Name = tok.Value
+			default:
+				return nil, p.unexpected(tok, "list index or map key")
+			}
+			if _, err := p.expect(RBracket); err != nil {
+				return nil, err
+			}
+			step.Span = p.spanFrom(stepStart)
+			path.Steps = append(path.Steps, step)
+			continue
+		}
+		break
+	}
+	path.Span = p.spanFrom(start)
+	return path, nil
+}
+
+// parseExpr parses an expression.
+func (p *parser) parseExpr() (Expr, error) {
+	return p.parseOr()
+}
+
+// parseOr parses OR expressions.
+func (p *parser) parseOr() (Expr, error) {
+	x, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("OR") {
+		y, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		x = &BinaryExpr{Span: Span{Start: x.Pos(), End: y.EndPos()}, Op: "OR", X: x, Y: y}
+	}
+	return x, nil
+}
+
+// parseAnd parses AND expressions.
+func (p *parser) parseAnd() (Expr, error) {
+	x, err := p.parseNot()
+	if err != nil {
+		return nil, err
+	}
+	for p.acceptKeyword("AND") {
+		y, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		x = &BinaryExpr{Span: Span{Start: x.Pos(), End: y.EndPos()}, Op: "AND", X: x, Y: y}
+	}
+	return x, nil
+}
+
+// parseNot parses NOT expressions.
+func (p *parser) parseNot() (Expr, error) {
+	start := p.peek().Pos
+	if p.acceptKeyword("NOT") {
+		x, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Span: p.spanFrom(start), Op: "NOT", X: x}, nil
+	}
+	return p.parseComparison()
+}
+
+// comparisonOperators is the list of comparison operators.
+var comparisonOperators = map[Kind]string{
+	Eq:  "=",
+	Neq: "<>",
+	Lt:  "<",
+	Le:  "<=",
+	Gt:  ">",
+	Ge:  ">=",
+}
+
+// parseComparison parses comparison, BETWEEN, IN and IS expressions.
+func (p *parser) parseComparison() (Expr, error) {
+	x, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	start := x.Pos()
+
+	if op, ok := comparisonOperators[p.peek().Kind]; ok {
+		p.next()
+		y, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &BinaryExpr{Span: p.spanFrom(start), Op: op, X: x, Y: y}, nil
+	}
+
+	if p.acceptKeyword("IS") {
+		expr := &IsExpr{X: x, Not: p.acceptKeyword("NOT")}
+		switch tok := p.next(); {
+		case tok.IsKeyword("NULL"):
+			expr.Kind = NullLiteral
+		case tok.IsKeyword("MISSING"):
+			expr.Kind = MissingLiteral
+		default:
+			return nil, p.unexpected(tok, "NULL or MISSING")
+		}
+		expr.Span = p.spanFrom(start)
+		return expr, nil
+	}
+
+	not := false
+	if p.peek().IsKeyword("NOT") && (p.peekN(1).IsKeyword("BETWEEN") || p.peekN(1).IsKeyword("IN")) {
+		p.next()
+		not = true
+	}
+	switch {
+	case p.acceptKeyword("BETWEEN"):
+		lo, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expectKeyword("AND"); err != nil {
+			return nil, err
+		}
+		hi, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		return &BetweenExpr{Span: p.spanFrom(start), X: x, Lo: lo, Hi: hi, Not: not}, nil
+	case p.acceptKeyword("IN"):
+		var closing Kind
+		switch tok := p.next(); tok.Kind {
+		case LBracket:
+			closing = RBracket
+		case LParen:
+			closing = RParen
+		default:
+			return nil, p.unexpected(tok, "[ or (")
+		}
+		list, err := p.parseExprList(closing)
+		if err != nil {
+			return nil, err
+		}
+		return &InExpr{Span: p.spanFrom(start), X: x, List: list, Not: not}, nil
+	}
+	return x, nil
+}
+
+// additiveOperators is the list of additive operators.
+var additiveOperators = map[Kind]string{
+	Plus:   "+",
+	Minus:  "-",
+	Concat: "||",
+}
+
+// parseAdditive parses additive expressions.
+func (p *parser) parseAdditive() (Expr, error) {
+	x, err := p.parseMultiplicative()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := additiveOperators[p.peek().Kind]
+		if !ok {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseMultiplicative()
+		if err != nil {
+			return nil, err
+		}
+		x = &BinaryExpr{Span: Span{Start: x.Pos(), End: y.EndPos()}, Op: op, X: x, Y: y}
+	}
+}
+
+// multiplicativeOperators is the list of multiplicative operators.
+var multiplicativeOperators = map[Kind]string{
+	Star:    "*",
+	Slash:   "/",
+	Percent: "%",
+}
+
+// parseMultiplicative parses multiplicative expressions.
+func (p *parser) parseMultiplicative() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for {
+		op, ok := multiplicativeOperators[p.peek().Kind]
+		if !ok {
+			return x, nil
+		}
+		p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		x = &BinaryExpr{Span: Span{Start: x.Pos(), End: y.EndPos()}, Op: op, X: x, Y: y}
+	}
+}
+
+// parseUnary parses unary expressions.
+func (p *parser) parseUnary() (Expr, error) {
+	tok := p.peek()
+	if tok.Kind == Minus || tok.Kind == Plus {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return &UnaryExpr{Span: p.spanFrom(tok.Pos), Op: tok.Text, X: x}, nil
+	}
+	return p.parsePrimary()
+}
+
+// parsePrimary parses literals, placeholders, paths, function calls and constructors.
+func (p *parser) parsePrimary() (Expr, error) {
+	tok := p.peek()
+	switch tok.Kind {
+	case String:
+		p.next()
+		return &Literal{Span: p.spanFrom(tok.Pos), Kind: StringLiteral, Value: tok.Value}, nil
+	case Number:
+		p.next()
+		return &Literal{Span: p.spanFrom(tok.Pos), Kind: NumberLiteral, Value: tok.Value}, nil
+	case Param:
+		p.next()
+		ph, err := p.newPlaceholder(tok)
+		if err != nil {
+			return nil, err
+		}
+		p.placeholders = append(p.placeholders, ph)
+		return ph, nil
+	case LParen:
+		p.next()
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(RParen); err != nil {
+			return nil, err
+		}
+		return &ParenExpr{Span: p.spanFrom(tok.Pos), X: x}, nil
+	case LBrace:
+		return p.parseTuple()
+	case LBracket:
+		p.next()
+		elems, err := p.parseExprList(RBracket)
+		if err != nil {
+			return nil, err
+		}
+		return &ListExpr{Span: p.spanFrom(tok.Pos), Elems: elems}, nil
+	case LBag:
+		p.next()
+		elems, err := p.parseExprList(RBag)
+		if err != nil {
+			return nil, err
+		}
+		return &BagExpr{Span: p.spanFrom(tok.Pos), Elems: elems}, nil
+	case QuotedIdent:
+		return p.parsePath()
+	case Ident:
+		switch {
+		case tok.IsKeyword("TRUE"), tok.IsKeyword("FALSE"):
+			p.next()
+			return &Literal{Span: p.spanFrom(tok.Pos), Kind: BoolLiteral, Value: strings.ToUpper(tok.Text)}, nil
+		case tok.IsKeyword("NULL"):
+			p.next()
+			return &Literal{Span: p.spanFrom(tok.Pos), Kind: NullLiteral, Value: "NULL"}, nil
+		case tok.IsKeyword("MISSING"):
+			p.next()
+			return &Literal{Span: p.spanFrom(tok.Pos), Kind: MissingLiteral, Value: "MISSING"}, nil
+		case p.peekN(1).Kind == LParen && !isReserved(tok):
+			return p.parseCall()
+		}
+		return p.parsePath()
+	}
+	return nil, p.unexpected(tok, "expression")
+}
+
+// parseCall parses a function call.
+func (p *parser) parseCall() (*CallExpr, error) {
+	name := p.next()
+	if _, err := p.expect(LParen); err != nil {
+		return nil, err
+	}
+	args, err := p.parseExprList(RParen)
+	if err != nil {
+		return nil, err
+	}
+	return &CallExpr{Span: p.spanFrom(name.Pos), Name: name.Text, Args: args}, nil
+}
+
+// parseTuple parses a tuple constructor.
+func (p *parser) parseTuple() (*TupleExpr, error) {
+	start, err := p.expect(LBrace)
+	if err != nil {
+		return nil, err
+	}
+	tuple := &TupleExpr{}
+	if p.peek().Kind == RBrace {
+		p.next()
+		tuple.Span = p.spanFrom(start.Pos)
+		return tuple, nil
+	}
+	for {
+		fieldStart := p.peek().Pos
+		key, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(Colon); err != nil {
+			return nil, err
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		tuple.Fields = append(tuple.Fields, &TupleField{Span: p.spanFrom(fieldStart), Key: key, Value: value})
+
+		tok := p.next()
+		if tok.Kind == RBrace {
+			break
+		}
+		if tok.Kind != Comma {
+			return nil, p.unexpected(tok, ", or }")
+		}
+	}
+	tuple.Span = p.spanFrom(start.Pos)
+	return tuple, nil
+}
+
+// parseExprList parses a comma-separated list of expressions terminated by closing.
+// The opening token must have been consumed.
+func (p *parser) parseExprList(closing Kind) ([]Expr, error) {
+	var list []Expr
+	if p.peek().Kind == closing {
+		p.next()
+		return list, nil
+	}
+	for {
+		x, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, x)
+
+		tok := p.next()
+		if tok.Kind == closing {
+			return list, nil
+		}
+		if tok.Kind != Comma {
+			return nil, p.unexpected(tok, ", or "+closing.String())
+		}
+	}
+}
+
+// isReserved reports whether the token is a reserved word.
+func isReserved(tok Token) bool {
+	if tok.Kind != Ident {
+		return false
+	}
+	_, ok := reservedWords[strings.ToUpper(tok.Text)]
+	return ok
+}
+
+// isAggregate reports whether the token is the name of an aggregate function.
+func isAggregate(tok Token) bool {
+	_, ok := aggregateFunctions[strings.ToUpper(tok.Text)]
+	return ok
+}
+
+// newPlaceholder returns the placeholder of the Param token.
+// The parameters in a statement must be written in the same style.
+func (p *parser) newPlaceholder(tok Token) (*Placeholder, error) {
+	ph := &Placeholder{Span: p.spanFrom(tok.Pos), Ordinal: len(p.placeholders) + 1}
+	switch tok.Text[0] {
+	case ':', '@':
+		ph.Name = tok.Value
+	case '$':
+		n, err := strconv.Atoi(tok.Value)
+		if err != nil || n < 1 {
+			return nil, errorf(tok.Pos, "invalid parameter number %s", tok.Text)
+		}
+		ph.Number = n
+	}
+	if len(p.placeholders) > 0 {
+		if first := p.placeholders[0]; first.Style() != ph.Style() {
+			return nil, errorf(tok.Pos, "cannot mix %s and %s parameters", first.Style(), ph.Style())
+		}
+	}
+	return ph, nil
+}
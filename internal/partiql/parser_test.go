@@ -0,0 +1,333 @@
+package partiql
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+func Test_Parse(t *testing.T) {
+	type want struct {
+		statement    string
+		placeholders int
+		table        *TableRef
+		columns      []string
+		hints        []string
+	}
+	type test struct {
+		query string
+		want  want
+	}
+
+	tableRef := func(name, index string) *TableRef {
+		return &TableRef{Name: name, Index: index}
+	}
+
+	tests := map[string]test{
+		"select": {
+			query: `SELECT id, name FROM "users" WHERE disabled = ?`,
+			want: want{
+				statement:    `SELECT id, name FROM "users" WHERE disabled = ?`,
+				placeholders: 1,
+				table:        tableRef("users", ""),
+				columns:      []string{"id", "name"},
+			},
+		},
+		"select-with-index": {
+			query: `SELECT * FROM "users"."gsi_pk-gsi_sk-index" WHERE gsi_pk = ? AND gsi_sk = ?`,
+			want: want{
+				statement:    `SELECT * FROM "users"."gsi_pk-gsi_sk-index" WHERE gsi_pk = ? AND gsi_sk = ?`,
+				placeholders: 2,
+				table:        tableRef("users", "gsi_pk-gsi_sk-index"),
+			},
+		},
+		"select-with-nested-paths": {
+			query: `SELECT address.city, tags[0], "user-info"['nick name'] FROM "users"`,
+			want: want{
+				statement: `SELECT address.city, tags[0], "user-info"['nick name'] FROM "users"`,
+				table:     tableRef("users", ""),
+				columns:   []string{"address.city", "tags[0]", "user-info.nick name"},
+			},
+		},
+		"select-with-aliases": {
+			query: `SELECT id AS user_id, address.city AS "City", tags[0] FROM "users"`,
+			want: want{
+				statement: `SELECT id AS user_id, address.city AS "City", tags[0] FROM "users"`,
+				table:     tableRef("users", ""),
+				columns:   []string{"user_id", "City", "tags[0]"},
+			},
+		},
+		"select-with-aggregates": {
+			query: `SELECT status, COUNT(*), sum(amount) AS total FROM "orders" WHERE pk = ? GROUP BY status LIMIT 10`,
+			want: want{
+				statement:    `SELECT status, COUNT(*), sum(amount) AS total FROM "orders" WHERE pk = ? GROUP BY status LIMIT 10`,
+				placeholders: 1,
+				table:        tableRef("orders", ""),
+				columns:      []string{"status", "COUNT(*)", "total"},
+			},
+		},
+		"select-with-string-literal-containing-keywords": {
+			query: `SELECT id FROM "users" WHERE note = 'WHERE ? RETURNING' AND id = ?`,
+			want: want{
+				statement:    `SELECT id FROM "users" WHERE note = 'WHERE ? RETURNING' AND id = ?`,
+				placeholders: 1,
+				table:        tableRef("users", ""),
+				columns:      []string{"id"},
+			},
+		},
+		"select-with-comments": {
+			query: "SELECT id -- the key\nFROM \"users\" /* ? */ WHERE id = ?;",
+			want: want{
+				statement:    "SELECT id  \nFROM \"users\"   WHERE id = ?",
+				placeholders: 1,
+				table:        tableRef("users", ""),
+				columns:      []string{"id"},
+			},
+		},
+		"select-with-hint": {
+			query: `SELECT /*+ allow_scan */ id FROM "users" WHERE name = ?`,
+			want: want{
+				statement:    `SELECT   id FROM "users" WHERE name = ?`,
+				placeholders: 1,
+				table:        tableRef("users", ""),
+				columns:      []string{"id"},
+				hints:        []string{"ALLOW_SCAN"},
+			},
+		},
+		"select-with-functions-and-order-by": {
+			query: `SELECT id FROM "users" WHERE pk = ? AND begins_with(sk, ?) AND size(tags) > 1 ORDER BY sk DESC`,
+			want: want{
+				statement:    `SELECT id FROM "users" WHERE pk = ? AND begins_with(sk, ?) AND size(tags) > 1 ORDER BY sk DESC`,
+				placeholders: 2,
+				table:        tableRef("users", ""),
+				columns:      []string{"id"},
+			},
+		},
+		"select-with-limit": {
+			query: `SELECT id FROM "users" WHERE pk = ? ORDER BY sk LIMIT 10 OFFSET 20`,
+			want: want{
+				statement:    `SELECT id FROM "users" WHERE pk = ? ORDER BY sk LIMIT 10 OFFSET 20`,
+				placeholders: 1,
+				table:        tableRef("users", ""),
+				columns:      []string{"id"},
+			},
+		},
+		"insert": {
+			query: `INSERT INTO "users" VALUE {'id': ?, 'tags': <<'a', 'b'>>, 'address': {'city': ?}, 'scores': [1, 2.5]}`,
+			want: want{
+				statement:    `INSERT INTO "users" VALUE {'id': ?, 'tags': <<'a', 'b'>>, 'address': {'city': ?}, 'scores': [1, 2.5]}`,
+				placeholders: 2,
+				table:        tableRef("users", ""),
+			},
+		},
+		"update-with-functions-in-set": {
+			query: `UPDATE "users" SET tags = list_append(tags, [?]) SET nick = ?, visits = visits + 1 REMOVE address.zip WHERE id = ? RETURNING ALL NEW id, tags`,
+			want: want{
+				statement:    `UPDATE "users" SET tags = list_append(tags, [?]) SET nick = ?, visits = visits + 1 REMOVE address.zip WHERE id = ? RETURNING ALL NEW id, tags`,
+				placeholders: 3,
+				table:        tableRef("users", ""),
+				columns:      []string{"id", "tags"},
+			},
+		},
+		"delete": {
+			query: `DELETE FROM "users" WHERE id = ? AND version IN [1, ?] AND nick IS NOT MISSING RETURNING ALL OLD *`,
+			want: want{
+				statement:    `DELETE FROM "users" WHERE id = ? AND version IN [1, ?] AND nick IS NOT MISSING RETURNING ALL OLD *`,
+				placeholders: 2,
+				table:        tableRef("users", ""),
+			},
+		},
+		"exists": {
+			query: `EXISTS(SELECT * FROM "users" WHERE id = ? AND age BETWEEN 20 AND ?)`,
+			want: want{
+				statement:    `EXISTS(SELECT * FROM "users" WHERE id = ? AND age BETWEEN 20 AND ?)`,
+				placeholders: 2,
+				table:        tableRef("users", ""),
+			},
+		},
+		"named-parameters": {
+			query: `UPDATE "users" SET profile = {'name': :name, 'age':age} WHERE id = @id AND name <> :name`,
+			want: want{
+				statement:    `UPDATE "users" SET profile = {'name': :name, 'age':age} WHERE id = @id AND name <> :name`,
+				placeholders: 3,
+				table:        tableRef("users", ""),
+			},
+		},
+		"numbered-parameters": {
+			query: `SELECT * FROM "users" WHERE id IN [$2, $1]`,
+			want: want{
+				statement:    `SELECT * FROM "users" WHERE id IN [$2, $1]`,
+				placeholders: 2,
+				table:        tableRef("users", ""),
+			},
+		},
+		"explain": {
+			query: `EXPLAIN SELECT id FROM "users" WHERE id = ?`,
+			want: want{
+				statement:    `EXPLAIN SELECT id FROM "users" WHERE id = ?`,
+				placeholders: 1,
+				table:        tableRef("users", ""),
+				columns:      []string{"id"},
+			},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				got, err := Parse(tt.query)
+				if err != nil {
+					t.Fatalf("Parse() unexpected error = %v", err)
+				}
+				if got.Text() != tt.want.statement {
+					t.Errorf("Parse().Text() = %q, want %q", got.Text(), tt.want.statement)
+				}
+				if diff := cmp.Diff(tt.want.hints, got.Hints); diff != "" {
+					t.Errorf("Parse().Hints mismatch (-want +got):\n%s", diff)
+				}
+				if len(got.Placeholders) != tt.want.placeholders {
+					t.Errorf("Parse().Placeholders = %d, want %d", len(got.Placeholders), tt.want.placeholders)
+				}
+				for i, ph := range got.Placeholders {
+					if ph.Ordinal != i+1 {
+						t.Errorf("Parse().Placeholders[%d].Ordinal = %d, want %d", i, ph.Ordinal, i+1)
+					}
+				}
+
+				var (
+					table      *TableRef
+					projection *Projection
+				)
+				statement := got.Statement
+				if explain, ok := statement.(*ExplainStatement); ok {
+					statement = explain.Statement
+				}
+				switch stmt := statement.(type) {
+				case *SelectStatement:
+					table, projection = stmt.Table, stmt.Projection
+				case *InsertStatement:
+					table = stmt.Table
+				case *UpdateStatement:
+					table = stmt.Table
+					if stmt.Returning != nil {
+						projection = stmt.Returning.Projection
+					}
+				case *DeleteStatement:
+					table = stmt.Table
+					if stmt.Returning != nil {
+						projection = stmt.Returning.Projection
+					}
+				case *ExistsStatement:
+					table = stmt.Select.Table
+				}
+				if diff := cmp.Diff(tt.want.table, table, cmpopts.IgnoreTypes(Span{})); diff != "" {
+					t.Errorf("Parse() table mismatch (-want +got):\n%s", diff)
+				}
+				var columns []string
+				if projection != nil {
+					for _, item := range projection.Items {
+						columns = append(columns, item.Name())
+					}
+				}
+				if diff := cmp.Diff(tt.want.columns, columns); diff != "" {
+					t.Errorf("Parse() columns mismatch (-want +got):\n%s", diff)
+				}
+			},
+		)
+	}
+}
+
+func Test_Parse_error(t *testing.T) {
+	type test struct {
+		query string
+		want  Position
+	}
+
+	tests := map[string]test{
+		"missing-table": {
+			query: `SELECT id FROM WHERE id = ?`,
+			want:  Position{Offset: 15, Line: 1, Column: 16},
+		},
+		"missing-projection": {
+			query: `SELECT FROM "users"`,
+			want:  Position{Offset: 7, Line: 1, Column: 8},
+		},
+		"missing-alias": {
+			query: `SELECT id AS FROM "users"`,
+			want:  Position{Offset: 13, Line: 1, Column: 14},
+		},
+		"ungrouped-column": {
+			query: `SELECT id, COUNT(*) FROM "orders" GROUP BY status`,
+			want:  Position{Offset: 7, Line: 1, Column: 8},
+		},
+		"sum-of-star": {
+			query: `SELECT SUM(*) FROM "orders"`,
+			want:  Position{Offset: 11, Line: 1, Column: 12},
+		},
+		"aggregate-in-returning": {
+			query: `DELETE FROM "orders" WHERE pk = ? RETURNING ALL OLD COUNT(*)`,
+			want:  Position{Offset: 57, Line: 1, Column: 58},
+		},
+		"unterminated-string": {
+			query: "SELECT id\nFROM \"users\"\nWHERE id = 'a",
+			want:  Position{Offset: 34, Line: 3, Column: 12},
+		},
+		"unterminated-comment": {
+			query: `SELECT id FROM "users" /* WHERE`,
+			want:  Position{Offset: 23, Line: 1, Column: 24},
+		},
+		"update-without-where": {
+			query: `UPDATE "users" SET name = ?`,
+			want:  Position{Offset: 27, Line: 1, Column: 28},
+		},
+		"trailing-tokens": {
+			query: `DELETE FROM "users" WHERE id = ? id`,
+			want:  Position{Offset: 33, Line: 1, Column: 34},
+		},
+		"create-table-without-hash-key": {
+			query: `CREATE TABLE "users" (sk N RANGE)`,
+			want:  Position{Offset: 21, Line: 1, Column: 22},
+		},
+		"alter-table-without-action": {
+			query: `ALTER TABLE "users" WAIT`,
+			want:  Position{Offset: 20, Line: 1, Column: 21},
+		},
+		"explain-ddl": {
+			query: `EXPLAIN DROP TABLE "users"`,
+			want:  Position{Offset: 8, Line: 1, Column: 9},
+		},
+		"negative-limit": {
+			query: `SELECT id FROM "users" LIMIT -1`,
+			want:  Position{Offset: 29, Line: 1, Column: 30},
+		},
+		"mixed-parameters": {
+			query: `SELECT id FROM "users" WHERE id = ? AND name = :name`,
+			want:  Position{Offset: 47, Line: 1, Column: 48},
+		},
+		"parameter-number-zero": {
+			query: `SELECT id FROM "users" WHERE id = $0`,
+			want:  Position{Offset: 34, Line: 1, Column: 35},
+		},
+		"multibyte-characters": {
+			query: `SELECT 名前 FROM "ユーザー" WHERE`,
+			want:  Position{Offset: 39, Line: 1, Column: 28},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				_, err := Parse(tt.query)
+				var perr *Error
+				if !errors.As(err, &perr) {
+					t.Fatalf("Parse() error = %v, want *Error", err)
+				}
+				if perr.Pos != tt.want {
+					t.Errorf("Parse() error position = %+v, want %+v", perr.Pos, tt.want)
+				}
+			},
+		)
+	}
+}
@@ -0,0 +1,96 @@
+package partiql
+
+import (
+	"slices"
+	"strings"
+)
+
+// Query is a parsed statement along with its source text.
+type Query struct {
+	// Source is the source text.
+	Source string
+
+	// Statement is the parsed statement.
+	Statement Statement
+
+	// Placeholders is the list of placeholders in order of appearance.
+	Placeholders []*Placeholder
+
+	// Hints is the list of hints in upper case, written in block comments starting with "/*+". e.g. /*+ ALLOW_SCAN */
+	Hints []string
+
+	// elided is the list of spans that are not sent to DynamoDB, such as comments and the trailing semicolon.
+	elided []Span
+}
+
+// HasHint reports whether the query has the hint.
+func (q *Query) HasHint(hint string) bool {
+	return slices.Contains(q.Hints, strings.ToUpper(hint))
+}
+
+// hintsFromComments returns the hints written in the comments.
+func hintsFromComments(src string, comments []Span) []string {
+	var hints []string
+	for _, c := range comments {
+		text := src[c.Start.Offset:c.End.Offset]
+		if !strings.HasPrefix(text, "/*+") {
+			continue
+		}
+		for _, v := range strings.Fields(strings.TrimSuffix(strings.TrimPrefix(text, "/*+"), "*/")) {
+			hints = append(hints, strings.ToUpper(v))
+		}
+	}
+	return hints
+}
+
+// Edit is a replacement of a range of the source text.
+type Edit struct {
+	Span
+
+	// Text is the replacement text.
+	Text string
+}
+
+// Rewrite returns the source text with edits applied.
+// Comments and the trailing semicolon are removed as well. Edits must not overlap each other.
+// If there is nothing to rewrite, the source text is returned as is.
+func (q *Query) Rewrite(edits ...Edit) string {
+	all := slices.Clone(edits)
+	for _, v := range q.elided {
+		overlapped := slices.ContainsFunc(
+			edits, func(e Edit) bool {
+				return e.Start.Offset < v.End.Offset && v.Start.Offset < e.End.Offset
+			},
+		)
+		if !overlapped {
+			all = append(all, Edit{Span: v, Text: " "})
+		}
+	}
+	if len(all) == 0 {
+		return q.Source
+	}
+	slices.SortFunc(
+		all, func(a, b Edit) int {
+			return a.Start.Offset - b.Start.Offset
+		},
+	)
+
+	var sb strings.Builder
+	cursor := 0
+	for _, e := range all {
+		if e.Start.Offset < cursor {
+			continue
+		}
+		sb.WriteString(q.Source[cursor:e.Start.Offset])
+		sb.WriteString(e.Text)
+		cursor = e.End.Offset
+	}
+	sb.WriteString(q.Source[cursor:])
+	return strings.TrimSpace(sb.String())
+}
+
+// Text returns the statement to be sent to DynamoDB.
+// See: Query.Rewrite
+func (q *Query) Text() string {
+	return q.Rewrite()
+}
@@ -0,0 +1,187 @@
+package partiql
+
+import "strings"
+
+// Position is a location in the source text.
+type Position struct {
+	// Offset is the byte offset, starting at 0.
+	Offset int
+
+	// Line is the line number, starting at 1.
+	Line int
+
+	// Column is the column number in runes, starting at 1.
+	Column int
+}
+
+// Kind is the kind of token.
+type Kind int
+
+// token kinds
+const (
+	// EOF is the end of the source text.
+	EOF Kind = iota
+
+	// Ident is an unquoted identifier or keyword. e.g. id, SELECT
+	Ident
+
+	// QuotedIdent is a double-quoted identifier. e.g. "users"
+	QuotedIdent
+
+	// String is a single-quoted string literal. e.g. 'Alice'
+	String
+
+	// Number is a numeric literal. e.g. 1, 1.5, 1e10
+	Number
+
+	// Param is a parameter. e.g. ?, :name, @name, $1
+	Param
+
+	// LParen is (
+	LParen
+
+	// RParen is )
+	RParen
+
+	// LBracket is [
+	LBracket
+
+	// RBracket is ]
+	RBracket
+
+	// LBrace is {
+	LBrace
+
+	// RBrace is }
+	RBrace
+
+	// LBag is <<
+	LBag
+
+	// RBag is >>
+	RBag
+
+	// Comma is ,
+	Comma
+
+	// Dot is .
+	Dot
+
+	// Colon is :
+	Colon
+
+	// Semicolon is ;
+	Semicolon
+
+	// Star is *
+	Star
+
+	// Eq is =
+	Eq
+
+	// Neq is <> or !=
+	Neq
+
+	// Lt is <
+	Lt
+
+	// Le is <=
+	Le
+
+	// Gt is >
+	Gt
+
+	// Ge is >=
+	Ge
+
+	// Plus is +
+	Plus
+
+	// Minus is -
+	Minus
+
+	// Slash is /
+	Slash
+
+	// Percent is %
+	Percent
+
+	// Concat is ||
+	Concat
+)
+
+var kindNames = map[Kind]string{
+	EOF:         "end of statement",
+	Ident:       "identifier",
+	QuotedIdent: "quoted identifier",
+	String:      "string",
+	Number:      "number",
+	Param:       "?",
+	LParen:      "(",
+	RParen:      ")",
+	LBracket:    "[",
+	RBracket:    "]",
+	LBrace:      "{",
+	RBrace:      "}",
+	LBag:        "<<",
+	RBag:        ">>",
+	Comma:       ",",
+	Dot:         ".",
+	Colon:       ":",
+	Semicolon:   ";",
+	Star:        "*",
+	Eq:          "=",
+	Neq:         "<>",
+	Lt:          "<",
+	Le:          "<=",
+	Gt:          ">",
+	Ge:          ">=",
+	Plus:        "+",
+	Minus:       "-",
+	Slash:       "/",
+	Percent:     "%",
+	Concat:      "||",
+}
+
+// String returns the string representation of the Kind.
+func (k Kind) String() string {
+	if v, ok := kindNames[k]; ok {
+		return v
+	}
+	return "unknown"
+}
+
+// Token is a lexical token.
+type Token struct {
+	// Kind is the kind of the token.
+	Kind Kind
+
+	// Text is the raw text of the token as written in the source.
+	Text string
+
+	// Value is the unquoted and unescaped value of String and QuotedIdent; otherwise same as Text.
+	Value string
+
+	// Pos is the position of the first character of the token.
+	Pos Position
+
+	// End is the position immediately after the token.
+	End Position
+}
+
+// IsKeyword reports whether the token is the given keyword.
+// Keywords are case-insensitive and never quoted.
+func (t Token) IsKeyword(keyword string) bool {
+	return t.Kind == Ident && strings.EqualFold(t.Text, keyword)
+}
+
+// String returns the string representation of the Token.
+func (t Token) String() string {
+	switch t.Kind {
+	case EOF:
+		return t.Kind.String()
+	case Ident, QuotedIdent, String, Number, Param:
+		return t.Text
+	}
+	return t.Kind.String()
+}
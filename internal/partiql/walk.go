@@ -0,0 +1,144 @@
+package partiql
+
+// Inspect traverses the syntax tree in depth-first order.
+// f is called for each node; if f returns false, the children of the node are skipped.
+func Inspect(node Node, f func(Node) bool) {
+	if node == nil || !f(node) {
+		return
+	}
+	switch n := node.(type) {
+	case *SelectStatement:
+		inspectIfNotNil(n.Projection, f)
+		inspectIfNotNil(n.Table, f)
+		inspectExpr(n.Where, f)
+		inspectIfNotNil(n.GroupBy, f)
+		for _, v := range n.OrderBy {
+			Inspect(v, f)
+		}
+		inspectIfNotNil(n.Limit, f)
+	case *InsertStatement:
+		inspectIfNotNil(n.Table, f)
+		inspectExpr(n.Value, f)
+	case *UpdateStatement:
+		inspectIfNotNil(n.Table, f)
+		for _, v := range n.Set {
+			Inspect(v, f)
+		}
+		for _, v := range n.Remove {
+			Inspect(v, f)
+		}
+		inspectExpr(n.Where, f)
+		inspectIfNotNil(n.Returning, f)
+	case *DeleteStatement:
+		inspectIfNotNil(n.Table, f)
+		inspectExpr(n.Where, f)
+		inspectIfNotNil(n.Returning, f)
+	case *ExistsStatement:
+		inspectIfNotNil(n.Select, f)
+	case *ExplainStatement:
+		if n.Statement != nil {
+			Inspect(n.Statement, f)
+		}
+	case *CreateTableStatement:
+		inspectIfNotNil(n.Table, f)
+		for _, v := range n.Keys {
+			Inspect(v, f)
+		}
+		for _, v := range n.Options {
+			Inspect(v, f)
+		}
+	case *DropTableStatement:
+		inspectIfNotNil(n.Table, f)
+	case *AlterTableStatement:
+		inspectIfNotNil(n.Table, f)
+		inspectIfNotNil(n.AddIndex, f)
+	case *IndexDefinition:
+		for _, v := range n.Keys {
+			Inspect(v, f)
+		}
+		for _, v := range n.Options {
+			Inspect(v, f)
+		}
+	case *Projection:
+		for _, v := range n.Items {
+			Inspect(v, f)
+		}
+	case *GroupByClause:
+		for _, v := range n.Keys {
+			Inspect(v, f)
+		}
+	case *ProjectionItem:
+		inspectExpr(n.Expr, f)
+	case *OrderByItem:
+		inspectExpr(n.Expr, f)
+	case *SetClause:
+		inspectIfNotNil(n.Path, f)
+		inspectExpr(n.Value, f)
+	case *RemoveClause:
+		inspectIfNotNil(n.Path, f)
+	case *Returning:
+		inspectIfNotNil(n.Projection, f)
+	case *Path:
+		for _, v := range n.Steps {
+			Inspect(v, f)
+		}
+	case *BinaryExpr:
+		inspectExpr(n.X, f)
+		inspectExpr(n.Y, f)
+	case *UnaryExpr:
+		inspectExpr(n.X, f)
+	case *BetweenExpr:
+		inspectExpr(n.X, f)
+		inspectExpr(n.Lo, f)
+		inspectExpr(n.Hi, f)
+	case *InExpr:
+		inspectExpr(n.X, f)
+		for _, v := range n.List {
+			inspectExpr(v, f)
+		}
+	case *IsExpr:
+		inspectExpr(n.X, f)
+	case *CallExpr:
+		for _, v := range n.Args {
+			inspectExpr(v, f)
+		}
+	case *AggregateExpr:
+		inspectIfNotNil(n.Arg, f)
+	case *TupleExpr:
+		for _, v := range n.Fields {
+			Inspect(v, f)
+		}
+	case *TupleField:
+		inspectExpr(n.Key, f)
+		inspectExpr(n.Value, f)
+	case *ListExpr:
+		for _, v := range n.Elems {
+			inspectExpr(v, f)
+		}
+	case *BagExpr:
+		for _, v := range n.Elems {
+			inspectExpr(v, f)
+		}
+	case *ParenExpr:
+		inspectExpr(n.X, f)
+	}
+}
+
+// inspectIfNotNil calls Inspect if node is not a nil pointer.
+func inspectIfNotNil[T any, P interface {
+	*T
+	Node
+}](node P, f func(Node) bool) {
+	if node == nil {
+		return
+	}
+	Inspect(node, f)
+}
+
+// inspectExpr calls Inspect if expr is not nil.
+func inspectExpr(expr Expr, f func(Node) bool) {
+	if expr == nil {
+		return
+	}
+	Inspect(expr, f)
+}
@@ -0,0 +1,61 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"iter"
+)
+
+// compatibility check
+var (
+	_ Querier = (*sql.DB)(nil)
+	_ Querier = (*sql.Conn)(nil)
+	_ Querier = (*sql.Tx)(nil)
+)
+
+// Querier is the interface of *sql.DB, *sql.Conn and *sql.Tx for Query.
+type Querier interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+// Query executes the query and returns an iterator over the rows decoded into T with ScanStruct.
+//
+// The rows are decoded from the attribute values as they are with WithAttributeValues, without loss of precision.
+// The pages are fetched lazily as the iteration goes, following the next tokens.
+// The rows are closed when the iteration ends, including when the loop exits early, and no more pages are fetched.
+// An error stops the iteration after it is yielded with the zero value of T.
+//
+// Example:
+//
+//	for user, err := range pqxd.Query[User](ctx, db, `SELECT id, name FROM "users"`) {
+//		if err != nil {
+//			return err
+//		}
+//		fmt.Println(user.Name)
+//	}
+func Query[T any](ctx context.Context, db Querier, query string, args ...any) iter.Seq2[T, error] {
+	return func(yield func(T, error) bool) {
+		var zero T
+		rows, err := db.QueryContext(WithAttributeValues(ctx), query, args...)
+		if err != nil {
+			yield(zero, err)
+			return
+		}
+		defer rows.Close()
+		for rows.NextResultSet() {
+			for rows.Next() {
+				var v T
+				if err := ScanStruct(rows, &v); err != nil {
+					yield(zero, err)
+					return
+				}
+				if !yield(v, nil) {
+					return
+				}
+			}
+		}
+		if err := rows.Err(); err != nil {
+			yield(zero, err)
+		}
+	}
+}
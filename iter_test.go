@@ -0,0 +1,104 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func TestQuery(t *testing.T) {
+	type test struct {
+		limit int
+		want  []testUser
+		calls int
+	}
+	tests := map[string]test{
+		"all-pages": {
+			limit: -1,
+			want:  []testUser{{ID: "1"}, {ID: "2"}, {ID: "3"}},
+			calls: 2,
+		},
+		"break-in-first-page": {
+			limit: 1,
+			want:  []testUser{{ID: "1"}},
+			calls: 1,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+
+				item := func(id string) map[string]types.AttributeValue {
+					return map[string]types.AttributeValue{"id": &types.AttributeValueMemberS{Value: id}}
+				}
+				WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+					ThenReturn(
+						&dynamodb.ExecuteStatementOutput{
+							Items:     []map[string]types.AttributeValue{item("1"), item("2")},
+							NextToken: aws.String("token"),
+						}, nil,
+					).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{Items: []map[string]types.AttributeValue{item("3")}}, nil).
+					Verify(Times(tt.calls))
+
+				db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client)))
+				defer db.Close()
+
+				var got []testUser
+				for user, err := range Query[testUser](context.Background(), db, `SELECT id FROM "users"`) {
+					if err != nil {
+						t.Fatalf("Query() unexpected error = %v", err)
+					}
+					got = append(got, user)
+					if len(got) == tt.limit {
+						break
+					}
+				}
+				if diff := cmp.Diff(tt.want, got); diff != "" {
+					t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+				}
+				if got := db.Stats().InUse; got != 0 {
+					t.Errorf("DB.Stats().InUse = %d, want 0", got)
+				}
+			},
+		)
+	}
+}
+
+func TestQuery_with_large_number(t *testing.T) {
+	type account struct {
+		ID int64 `dynamodbav:"id"`
+	}
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{{"id": &types.AttributeValueMemberN{Value: "9007199254740993"}}},
+			}, nil,
+		).
+		Verify(Times(1))
+
+	db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client)))
+	defer db.Close()
+
+	var got []account
+	for v, err := range Query[account](context.Background(), db, `SELECT id FROM "accounts"`) {
+		if err != nil {
+			t.Fatalf("Query() unexpected error = %v", err)
+		}
+		got = append(got, v)
+	}
+	if diff := cmp.Diff([]account{{ID: 9007199254740993}}, got); diff != "" {
+		t.Errorf("Query() mismatch (-want +got):\n%s", diff)
+	}
+}
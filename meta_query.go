@@ -8,6 +8,15 @@ import (
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
 )
 
+// meta-tables
+const (
+	// metaTableDescribeTable is the meta-table for DescribeTable API
+	metaTableDescribeTable = "!pqxd_describe_table"
+
+	// metaTableListTables is the meta-table for ListTables API
+	metaTableListTables = "!pqxd_list_tables"
+)
+
 var describeTableColumns = []string{
 	"ArchivalSummary",
 	"AttributeDefinitions",
@@ -29,7 +38,7 @@ var describeTableColumns = []string{
 	"TableStatus",
 }
 
-// describeTable performs a DescribeTable API.
+// describeTable performs a DescribeTable API, and refreshes the schema cached in the connector with the result.
 // See: https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/service/dynamodb#Client.DeleteTable
 func (c *connection) describeTable(
 	ctx context.Context, targetTable string, selectedList []string, args []driver.NamedValue,
@@ -45,20 +54,12 @@ func (c *connection) describeTable(
 			targetTable = args[0].Value.(string)
 		}
 	}
-	output, err := c.client.DescribeTable(
-		ctx, &dynamodb.DescribeTableInput{
-			TableName: &targetTable,
-		},
-	)
+	table, err := c.setting.schemaCache.refresh(ctx, c.client, targetTable)
 	if err != nil {
 		return nil, err
 	}
-	if output.Table == nil {
-		return nil, nil
-	}
-	table := output.Table
 	if table == nil {
-		table = &types.TableDescription{}
+		return nil, nil
 	}
 	if selectedList[0] == "*" {
 		selectedList = describeTableColumns
@@ -0,0 +1,158 @@
+package pqxd
+
+import (
+	"database/sql"
+	"encoding/json"
+	"reflect"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// NumberMode is the Go type of N attributes returned by driver.Rows.Next.
+//
+// With NumberModeString, NumberModeJSONNumber and NumberModeDecimal, sql.Rows.Scan into int64 is exact,
+// and returns an error on overflow. Scan into string is exact with NumberModeString and NumberModeJSONNumber.
+// With NumberModeDecimal, scan into *Decimal instead of *string, since database/sql does not convert Decimal into string.
+// BigInt and BigFloat scan into *big.Int and *big.Float exactly in any mode.
+type NumberMode int
+
+const (
+	// NumberModeFloat64 returns float64, which may lose precision. It is the default.
+	NumberModeFloat64 NumberMode = iota
+
+	// NumberModeString returns string.
+	NumberModeString
+
+	// NumberModeJSONNumber returns json.Number.
+	NumberModeJSONNumber
+
+	// NumberModeDecimal returns Decimal. It can be scanned into *Decimal, int64 and float64, but not into string.
+	NumberModeDecimal
+)
+
+// numberDecoder decodes N attributes into attributevalue.Number instead of float64.
+var numberDecoder = attributevalue.NewDecoder(
+	func(o *attributevalue.DecoderOptions) {
+		o.UseNumber = true
+	},
+)
+
+// decode decodes the attribute value into the value returned by driver.Rows.Next.
+func (m NumberMode) decode(av types.AttributeValue) (any, error) {
+	var value any
+	if m == NumberModeFloat64 {
+		if err := attributevalue.Unmarshal(av, &value); err != nil {
+			return nil, err
+		}
+		return value, nil
+	}
+	if err := numberDecoder.Decode(av, &value); err != nil {
+		return nil, err
+	}
+	return m.convert(value)
+}
+
+// convert replaces attributevalue.Number in the decoded value with the type of the mode, recursively.
+func (m NumberMode) convert(value any) (any, error) {
+	switch v := value.(type) {
+	case attributevalue.Number:
+		return m.number(v)
+	case []attributevalue.Number:
+		set := make([]any, 0, len(v))
+		for _, n := range v {
+			elem, err := m.number(n)
+			if err != nil {
+				return nil, err
+			}
+			set = append(set, elem)
+		}
+		return m.numberSet(set), nil
+	case []any:
+		for i, elem := range v {
+			converted, err := m.convert(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = converted
+		}
+	case map[string]any:
+		for k, elem := range v {
+			converted, err := m.convert(elem)
+			if err != nil {
+				return nil, err
+			}
+			v[k] = converted
+		}
+	}
+	return value, nil
+}
+
+// number converts the number into the type of the mode.
+func (m NumberMode) number(n attributevalue.Number) (any, error) {
+	switch m {
+	case NumberModeString:
+		return n.String(), nil
+	case NumberModeJSONNumber:
+		return json.Number(n), nil
+	case NumberModeDecimal:
+		return ParseDecimal(n.String())
+	}
+	f, err := n.Float64()
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// numberSet converts the elements of NS into a typed slice of the mode.
+func (m NumberMode) numberSet(set []any) any {
+	switch m {
+	case NumberModeString:
+		return toTypedSlice[string](set)
+	case NumberModeJSONNumber:
+		return toTypedSlice[json.Number](set)
+	case NumberModeDecimal:
+		return toTypedSlice[Decimal](set)
+	}
+	return toTypedSlice[float64](set)
+}
+
+// scanType returns the Go type of N attributes for driver.RowsColumnTypeScanType.
+func (m NumberMode) scanType(nullable bool) reflect.Type {
+	switch m {
+	case NumberModeString:
+		if nullable {
+			return reflect.TypeFor[sql.NullString]()
+		}
+		return reflect.TypeFor[string]()
+	case NumberModeJSONNumber:
+		if nullable {
+			return reflect.TypeFor[sql.Null[json.Number]]()
+		}
+		return reflect.TypeFor[json.Number]()
+	case NumberModeDecimal:
+		if nullable {
+			return reflect.TypeFor[sql.Null[Decimal]]()
+		}
+		return reflect.TypeFor[Decimal]()
+	}
+	if nullable {
+		return reflect.TypeFor[sql.NullFloat64]()
+	}
+	return reflect.TypeFor[float64]()
+}
+
+// setScanType returns the Go type of NS attributes for driver.RowsColumnTypeScanType.
+func (m NumberMode) setScanType() reflect.Type {
+	return reflect.SliceOf(m.scanType(false))
+}
+
+// toTypedSlice converts []any into []T.
+func toTypedSlice[T any](s []any) []T {
+	typed := make([]T, 0, len(s))
+	for _, v := range s {
+		typed = append(typed, v.(T))
+	}
+	return typed
+}
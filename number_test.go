@@ -0,0 +1,261 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"math"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func TestDecimal(t *testing.T) {
+	type want struct {
+		str   string
+		int64 int64
+		err   error
+	}
+	type test struct {
+		src  string
+		want want
+	}
+	tests := map[string]test{
+		"integer": {
+			src:  "12345",
+			want: want{str: "12345", int64: 12345},
+		},
+		"negative-fraction": {
+			src:  "-0.05",
+			want: want{str: "-0.05", err: ErrInexactNumber},
+		},
+		"exponent": {
+			src:  "1.5E+3",
+			want: want{str: "1500", int64: 1500},
+		},
+		"negative-exponent": {
+			src:  "15e-3",
+			want: want{str: "0.015", err: ErrInexactNumber},
+		},
+		"trailing-zeros": {
+			src:  "2.000",
+			want: want{str: "2.000", int64: 2},
+		},
+		"overflow": {
+			src:  "9223372036854775808",
+			want: want{str: "9223372036854775808", err: ErrNumberOutOfRange},
+		},
+		"38-digits": {
+			src:  "12345678901234567890123456789012345678",
+			want: want{str: "12345678901234567890123456789012345678", err: ErrNumberOutOfRange},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				d, err := ParseDecimal(tt.src)
+				if err != nil {
+					t.Fatalf("ParseDecimal() unexpected error = %v", err)
+				}
+				if got := d.String(); got != tt.want.str {
+					t.Errorf("String() = %q, want %q", got, tt.want.str)
+				}
+				got, err := d.Int64()
+				if !errors.Is(err, tt.want.err) {
+					t.Errorf("Int64() error = %v, want %v", err, tt.want.err)
+				}
+				if got != tt.want.int64 {
+					t.Errorf("Int64() = %d, want %d", got, tt.want.int64)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_QueryContext_with_NumberMode(t *testing.T) {
+	const large = "12345678901234567890123456789012345678"
+	type test struct {
+		mode     NumberMode
+		want     []driver.Value
+		scanType reflect.Type
+	}
+	tests := map[string]test{
+		"float64": {
+			mode: NumberModeFloat64,
+			want: []driver.Value{
+				1.2345678901234568e+37,
+				[]float64{1, 2.5},
+				map[string]any{"n": 0.1},
+			},
+			scanType: reflect.TypeFor[float64](),
+		},
+		"string": {
+			mode: NumberModeString,
+			want: []driver.Value{
+				large,
+				[]string{"1", "2.5"},
+				map[string]any{"n": "0.1"},
+			},
+			scanType: reflect.TypeFor[string](),
+		},
+		"json-number": {
+			mode: NumberModeJSONNumber,
+			want: []driver.Value{
+				json.Number(large),
+				[]json.Number{"1", "2.5"},
+				map[string]any{"n": json.Number("0.1")},
+			},
+			scanType: reflect.TypeFor[json.Number](),
+		},
+		"decimal": {
+			mode: NumberModeDecimal,
+			want: []driver.Value{
+				must(ParseDecimal(large)),
+				[]Decimal{must(ParseDecimal("1")), must(ParseDecimal("2.5"))},
+				map[string]any{"n": must(ParseDecimal("0.1"))},
+			},
+			scanType: reflect.TypeFor[Decimal](),
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+
+				WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+					ThenReturn(
+						&dynamodb.ExecuteStatementOutput{
+							Items: []map[string]types.AttributeValue{
+								{
+									"id":     &types.AttributeValueMemberN{Value: large},
+									"scores": &types.AttributeValueMemberNS{Value: []string{"1", "2.5"}},
+									"nested": &types.AttributeValueMemberM{
+										Value: map[string]types.AttributeValue{
+											"n": &types.AttributeValueMemberN{Value: "0.1"},
+										},
+									},
+								},
+							},
+						}, nil,
+					)
+
+				sut := newConnection(client, WithNumberMode(tt.mode))
+				rows, err := sut.QueryContext(context.Background(), `SELECT id, scores, nested FROM "users"`, nil)
+				if err != nil {
+					t.Fatalf("QueryContext() unexpected error = %v", err)
+				}
+				defer rows.Close()
+
+				if got := rows.(driver.RowsColumnTypeScanType).ColumnTypeScanType(0); got != tt.scanType {
+					t.Errorf("ColumnTypeScanType() = %v, want %v", got, tt.scanType)
+				}
+				got := make([]driver.Value, 3)
+				if err := rows.Next(got); err != nil {
+					t.Fatalf("Next() unexpected error = %v", err)
+				}
+				if !reflect.DeepEqual(got, tt.want) {
+					t.Errorf("Next() = %v, want %v", got, tt.want)
+				}
+				if err := rows.Next(got); !errors.Is(err, io.EOF) {
+					t.Errorf("Next() error = %v, want %v", err, io.EOF)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_ExecContext_with_Decimal(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	input := &dynamodb.ExecuteStatementInput{
+		Statement: aws.String(`UPDATE "users" SET balance = ? WHERE id = ?`),
+		Parameters: []types.AttributeValue{
+			&types.AttributeValueMemberN{Value: "0.000000000000000000000000000000000001"},
+			&types.AttributeValueMemberN{Value: "9223372036854775808"},
+		},
+	}
+	WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+		ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil).
+		Verify(Times(1))
+
+	sut := newConnection(client)
+	args := []driver.NamedValue{
+		{Ordinal: 1, Value: must(ParseDecimal("1E-36"))},
+		{Ordinal: 2, Value: json.Number("9223372036854775808")},
+	}
+	for i := range args {
+		if err := sut.CheckNamedValue(&args[i]); err != nil {
+			t.Fatalf("CheckNamedValue() unexpected error = %v", err)
+		}
+	}
+	if _, err := sut.ExecContext(context.Background(), *input.Statement, args); err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+}
+
+func TestBigInt(t *testing.T) {
+	var got big.Int
+	if err := BigInt(&got).Scan(json.Number("123456789012345678901234567890")); err != nil {
+		t.Fatalf("Scan() unexpected error = %v", err)
+	}
+	if got.String() != "123456789012345678901234567890" {
+		t.Errorf("Scan() = %s, want %s", got.String(), "123456789012345678901234567890")
+	}
+	if err := BigInt(&got).Scan("1.5"); !errors.Is(err, ErrInexactNumber) {
+		t.Errorf("Scan() error = %v, want %v", err, ErrInexactNumber)
+	}
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		panic(err)
+	}
+	return v
+}
+
+func Test_NumberModeDecimal_Scan(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	item := map[string]types.AttributeValue{
+		"id":      &types.AttributeValueMemberN{Value: "9223372036854775807"},
+		"balance": &types.AttributeValueMemberN{Value: "0.000000000000000000000000000000000001"},
+	}
+	WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+		ThenReturn(&dynamodb.ExecuteStatementOutput{Items: []map[string]types.AttributeValue{item}}, nil).
+		ThenReturn(&dynamodb.ExecuteStatementOutput{Items: []map[string]types.AttributeValue{item}}, nil)
+
+	db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client), WithNumberMode(NumberModeDecimal)))
+	defer db.Close()
+	query := `SELECT id, balance FROM "accounts"`
+
+	var (
+		id      int64
+		balance Decimal
+	)
+	if err := db.QueryRowContext(context.Background(), query).Scan(&id, &balance); err != nil {
+		t.Fatalf("Scan() unexpected error = %v", err)
+	}
+	if id != math.MaxInt64 {
+		t.Errorf("Scan() id = %d, want %d", id, int64(math.MaxInt64))
+	}
+	if got := balance.String(); got != "0.000000000000000000000000000000000001" {
+		t.Errorf("Scan() balance = %s, want %s", got, "0.000000000000000000000000000000000001")
+	}
+
+	// Decimal is not converted into string by database/sql.
+	var s string
+	if err := db.QueryRowContext(context.Background(), query).Scan(&id, &s); err == nil {
+		t.Errorf("Scan() into *string error = nil, want error")
+	}
+}
@@ -0,0 +1,96 @@
+package pqxd
+
+import (
+	"database/sql/driver"
+	"fmt"
+	"slices"
+
+	"github.com/miyamo2/pqxd/internal/partiql"
+)
+
+// parameterRef refers to the argument bound to a named or numbered placeholder.
+type parameterRef struct {
+	// text is the placeholder as written in the query. e.g. :name, $1
+	text string
+
+	// name is the name of the argument given with sql.Named. empty if numbered.
+	name string
+
+	// ordinal is the position of the argument, starting at 1. zero if named.
+	ordinal int
+}
+
+// matches reports whether the argument is bound to the placeholder.
+func (r parameterRef) matches(arg driver.NamedValue) bool {
+	if r.name != "" {
+		return arg.Name == r.name
+	}
+	return arg.Name == "" && arg.Ordinal == r.ordinal
+}
+
+// applyParameters sets the parameters and the number of arguments from the named or numbered placeholders,
+// and returns the edits to replace them with ?, since DynamoDB only accepts positional placeholders.
+//
+// A named placeholder used more than once is bound to the same argument,
+// and the numbered placeholders require as many arguments as the largest number.
+func (p *queryPlan) applyParameters() []partiql.Edit {
+	placeholders := p.query.Placeholders
+	if len(placeholders) == 0 || placeholders[0].Style() == "?" {
+		return nil
+	}
+	edits := make([]partiql.Edit, 0, len(placeholders))
+	names := make(map[string]struct{})
+	p.numInput = 0
+	p.parameters = make([]parameterRef, 0, len(placeholders))
+	for _, ph := range placeholders {
+		ref := parameterRef{text: p.sourceOf(ph), name: ph.Name, ordinal: ph.Number}
+		p.parameters = append(p.parameters, ref)
+		edits = append(edits, partiql.Edit{Span: ph.Span, Text: "?"})
+		if ph.Name != "" {
+			names[ph.Name] = struct{}{}
+			p.numInput = len(names)
+			continue
+		}
+		p.numInput = max(p.numInput, ph.Number)
+	}
+	return edits
+}
+
+// bindArgs returns the arguments in order of the placeholders in the statement sent to DynamoDB.
+//
+// It returns ErrInvalidArgument if an argument of a placeholder is missing, or an argument is not used.
+func (p *queryPlan) bindArgs(args []driver.NamedValue) ([]driver.NamedValue, error) {
+	if p.explain != nil {
+		// the parameters are not evaluated.
+		return args, nil
+	}
+	if p.parameters == nil {
+		for _, arg := range args {
+			if arg.Name != "" {
+				return nil, fmt.Errorf("%w: named argument %q is given to ? placeholders", ErrInvalidArgument, arg.Name)
+			}
+		}
+		return args, nil
+	}
+
+	bound := make([]driver.NamedValue, 0, len(p.parameters))
+	used := make([]bool, len(args))
+	for i, ref := range p.parameters {
+		index := slices.IndexFunc(args, ref.matches)
+		if index < 0 {
+			return nil, fmt.Errorf("%w: missing argument for %s", ErrInvalidArgument, ref.text)
+		}
+		used[index] = true
+		bound = append(bound, driver.NamedValue{Ordinal: i + 1, Value: args[index].Value})
+	}
+	for i, arg := range args {
+		if used[i] {
+			continue
+		}
+		if arg.Name != "" {
+			return nil, fmt.Errorf("%w: argument %q is not used in the statement", ErrInvalidArgument, arg.Name)
+		}
+		return nil, fmt.Errorf("%w: argument #%d is not used in the statement", ErrInvalidArgument, arg.Ordinal)
+	}
+	return bound, nil
+}
@@ -0,0 +1,125 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func Test_queryPlan_bindArgs(t *testing.T) {
+	type want struct {
+		args []driver.NamedValue
+		err  error
+	}
+	type test struct {
+		query string
+		args  []driver.NamedValue
+		want  want
+	}
+	tests := map[string]test{
+		"named": {
+			query: `UPDATE "users" SET name = :name WHERE id = :id AND name <> :name`,
+			args: []driver.NamedValue{
+				{Ordinal: 1, Name: "id", Value: "1"},
+				{Ordinal: 2, Name: "name", Value: "Alice"},
+			},
+			want: want{
+				args: []driver.NamedValue{
+					{Ordinal: 1, Value: "Alice"},
+					{Ordinal: 2, Value: "1"},
+					{Ordinal: 3, Value: "Alice"},
+				},
+			},
+		},
+		"numbered": {
+			query: `SELECT * FROM "users" WHERE id = $2 OR id = $1`,
+			args:  []driver.NamedValue{{Ordinal: 1, Value: "1"}, {Ordinal: 2, Value: "2"}},
+			want: want{
+				args: []driver.NamedValue{{Ordinal: 1, Value: "2"}, {Ordinal: 2, Value: "1"}},
+			},
+		},
+		"positional": {
+			query: `SELECT * FROM "users" WHERE id = ?`,
+			args:  []driver.NamedValue{{Ordinal: 1, Value: "1"}},
+			want: want{
+				args: []driver.NamedValue{{Ordinal: 1, Value: "1"}},
+			},
+		},
+		"missing-name": {
+			query: `SELECT * FROM "users" WHERE id = :id`,
+			args:  []driver.NamedValue{{Ordinal: 1, Name: "pk", Value: "1"}},
+			want:  want{err: ErrInvalidArgument},
+		},
+		"extra-name": {
+			query: `SELECT * FROM "users" WHERE id = :id`,
+			args: []driver.NamedValue{
+				{Ordinal: 1, Name: "id", Value: "1"},
+				{Ordinal: 2, Name: "name", Value: "Alice"},
+			},
+			want: want{err: ErrInvalidArgument},
+		},
+		"named-argument-for-positional": {
+			query: `SELECT * FROM "users" WHERE id = ?`,
+			args:  []driver.NamedValue{{Ordinal: 1, Name: "id", Value: "1"}},
+			want:  want{err: ErrInvalidArgument},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				plan, err := newQueryPlan(tt.query)
+				if err != nil {
+					t.Fatalf("newQueryPlan() unexpected error = %v", err)
+				}
+				got, err := plan.bindArgs(tt.args)
+				if !errors.Is(err, tt.want.err) {
+					t.Fatalf("bindArgs() error = %v, want %v", err, tt.want.err)
+				}
+				if diff := cmp.Diff(tt.want.args, got); diff != "" {
+					t.Errorf("bindArgs() mismatch (-want +got):\n%s", diff)
+				}
+			},
+		)
+	}
+}
+
+func Test_Stmt_ExecContext_with_named_parameters(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	input := &dynamodb.ExecuteStatementInput{
+		Statement: aws.String(`UPDATE "users" SET name = ? WHERE id = ? AND name <> ?`),
+		Parameters: []types.AttributeValue{
+			&types.AttributeValueMemberS{Value: "Alice"},
+			&types.AttributeValueMemberS{Value: "1"},
+			&types.AttributeValueMemberS{Value: "Alice"},
+		},
+	}
+	WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+		ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil).
+		Verify(Times(1))
+
+	db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client)))
+	defer db.Close()
+	stmt, err := db.Prepare(`UPDATE "users" SET name = :name WHERE id = :id AND name <> :name`)
+	if err != nil {
+		t.Fatalf("Prepare() unexpected error = %v", err)
+	}
+	defer stmt.Close()
+
+	ctx := context.Background()
+	if _, err := stmt.ExecContext(ctx, sql.Named("id", "1"), sql.Named("name", "Alice")); err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+	if _, err := stmt.ExecContext(ctx, sql.Named("id", "1")); err == nil {
+		t.Errorf("ExecContext() with missing argument, want error")
+	}
+}
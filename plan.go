@@ -0,0 +1,281 @@
+package pqxd
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/miyamo2/pqxd/internal/partiql"
+)
+
+// queryPlan is the result of analyzing a query string.
+type queryPlan struct {
+	// query is the parsed query
+	query *partiql.Query
+
+	// statement is the PartiQL statement to be sent to DynamoDB
+	statement string
+
+	// selectedList is a list of selected items. empty if the statement returns no rows.
+	selectedList []string
+
+	// selectedPaths are the document paths of the selected items in order.
+	// nil if every item is the top-level attribute of its column name.
+	selectedPaths []*partiql.Path
+
+	// numInput is the number of arguments of the statement. -1 if not checked.
+	numInput int
+
+	// parameters are the arguments bound to the placeholders of the statement in order.
+	// nil if the placeholders are written as ?.
+	parameters []parameterRef
+
+	// describeTableTarget is the target table of !pqxd_describe_table. "?" if given as a parameter.
+	describeTableTarget string
+
+	// listTable if true, the statement targets !pqxd_list_tables
+	listTable bool
+
+	// ddl is the control-plane API call of a DDL statement. nil if not DDL.
+	ddl *ddlOperation
+
+	// limit is the LIMIT clause evaluated on the client side. nil if omitted.
+	limit *partiql.LimitClause
+
+	// aggregate is the aggregation evaluated on the client side. nil if not aggregated.
+	aggregate *aggregatePlan
+
+	// explain is the plan of the statement explained by EXPLAIN. nil if not EXPLAIN.
+	explain *queryPlan
+}
+
+// newQueryPlan parses the query string and returns a new queryPlan
+func newQueryPlan(query string) (*queryPlan, error) {
+	q, err := partiql.Parse(query)
+	if err != nil {
+		return nil, toSyntaxError(err)
+	}
+	plan := &queryPlan{
+		query:     q,
+		statement: q.Text(),
+		numInput:  len(q.Placeholders),
+	}
+	edits := plan.applyParameters()
+
+	switch stmt := q.Statement.(type) {
+	case *partiql.SelectStatement:
+		switch stmt.Table.Name {
+		case metaTableDescribeTable:
+			target, err := describeTableTargetFromWhere(stmt)
+			if err != nil {
+				return nil, err
+			}
+			plan.describeTableTarget = target
+		case metaTableListTables:
+			if !stmt.Projection.Star || stmt.Where != nil || stmt.Limit != nil {
+				return nil, newSyntaxError(stmt.Pos(), `only "SELECT * FROM \"%s\"" is supported`, metaTableListTables)
+			}
+			plan.listTable = true
+		}
+		if stmt.Aggregated() {
+			edits = append(edits, plan.applyAggregate(stmt)...)
+		} else {
+			edits = append(edits, plan.applyProjection(stmt.Projection)...)
+		}
+		if stmt.Limit != nil {
+			// DynamoDB does not support LIMIT, so the clause is removed from the statement.
+			plan.limit = stmt.Limit
+			edits = append(edits, partiql.Edit{Span: stmt.Limit.Span})
+		}
+	case *partiql.UpdateStatement:
+		edits = append(edits, plan.applyReturning(stmt.Returning)...)
+	case *partiql.DeleteStatement:
+		edits = append(edits, plan.applyReturning(stmt.Returning)...)
+	case *partiql.ExplainStatement:
+		if plan.explain, err = newQueryPlan(q.Source[stmt.Statement.Pos().Offset:]); err != nil {
+			return nil, err
+		}
+		plan.selectedList = explainColumns
+		// the parameters are not evaluated, so any number of arguments is accepted.
+		plan.numInput = -1
+	case *partiql.CreateTableStatement, *partiql.DropTableStatement, *partiql.AlterTableStatement:
+		if plan.ddl, err = newDDLOperation(stmt); err != nil {
+			return nil, err
+		}
+	}
+	if len(edits) > 0 {
+		plan.statement = q.Rewrite(edits...)
+	}
+	return plan, nil
+}
+
+// readIndex returns the table and the secondary index the statement reads from. index is empty if not reading an index.
+func (p *queryPlan) readIndex() (table string, index string) {
+	stmt, ok := p.query.Statement.(*partiql.SelectStatement)
+	if !ok {
+		return "", ""
+	}
+	return stmt.Table.Name, stmt.Table.Index
+}
+
+// target returns the target table and the WHERE condition of SELECT, UPDATE, DELETE and EXISTS.
+// ok is false for the other statements and meta-tables.
+func (p *queryPlan) target() (table *partiql.TableRef, where partiql.Expr, ok bool) {
+	if p.listTable || p.describeTableTarget != "" {
+		return nil, nil, false
+	}
+	switch stmt := p.query.Statement.(type) {
+	case *partiql.SelectStatement:
+		return stmt.Table, stmt.Where, true
+	case *partiql.UpdateStatement:
+		return stmt.Table, stmt.Where, true
+	case *partiql.DeleteStatement:
+		return stmt.Table, stmt.Where, true
+	case *partiql.ExistsStatement:
+		return stmt.Select.Table, stmt.Select.Where, true
+	}
+	return nil, nil, false
+}
+
+// sourceOf returns the source text of the node.
+func (p *queryPlan) sourceOf(node partiql.Node) string {
+	return p.query.Source[node.Pos().Offset:node.EndPos().Offset]
+}
+
+// txStatementKind is the kind of statement in a transaction.
+type txStatementKind int
+
+const (
+	// txStatementRead is a read statement. e.g. SELECT
+	txStatementRead txStatementKind = iota + 1
+
+	// txStatementWrite is a write statement. e.g. INSERT, UPDATE, DELETE and EXISTS
+	txStatementWrite
+)
+
+// txStatementKind returns the kind of the statement in a transaction. zero if not supported within transaction.
+//
+// EXISTS is a write statement since it is a condition check of a write transaction.
+func (p *queryPlan) txStatementKind() txStatementKind {
+	switch p.query.Statement.(type) {
+	case *partiql.SelectStatement:
+		return txStatementRead
+	case *partiql.InsertStatement, *partiql.UpdateStatement, *partiql.DeleteStatement, *partiql.ExistsStatement:
+		return txStatementWrite
+	}
+	return 0
+}
+
+// batchable reports whether the statement can be executed with BatchExecuteStatement API.
+// Only INSERT, UPDATE and DELETE without RETURNING are supported.
+func (p *queryPlan) batchable() bool {
+	switch stmt := p.query.Statement.(type) {
+	case *partiql.InsertStatement:
+		return true
+	case *partiql.UpdateStatement:
+		return stmt.Returning == nil
+	case *partiql.DeleteStatement:
+		return stmt.Returning == nil
+	}
+	return false
+}
+
+// applyReturning sets the selected list from the RETURNING clause.
+// Since DynamoDB only accepts `*`, it returns the edit to replace the column list with `*` in the statement.
+func (p *queryPlan) applyReturning(returning *partiql.Returning) []partiql.Edit {
+	if returning == nil {
+		return nil
+	}
+	p.applyProjection(returning.Projection)
+	if returning.Projection.Star {
+		return nil
+	}
+	return []partiql.Edit{{Span: returning.Projection.Span, Text: "*"}}
+}
+
+// applyProjection sets the selected list and the selected paths from the projection.
+//
+// DynamoDB returns a nested path within its top-level attribute, e.g. {"address": {"city": ...}} for address.city,
+// with the list elements not selected left out, and does not accept aliases.
+// So if the projection has nested paths or aliases, it returns the edit to select their top-level attributes instead,
+// and the paths are resolved against the returned items on the client side.
+func (p *queryPlan) applyProjection(projection *partiql.Projection) []partiql.Edit {
+	if projection.Star {
+		p.selectedList = []string{"*"}
+		return nil
+	}
+	p.selectedList = make([]string, 0, len(projection.Items))
+	paths := make([]*partiql.Path, 0, len(projection.Items))
+	roots := make([]string, 0, len(projection.Items))
+	seen := make(map[string]struct{}, len(projection.Items))
+	rewrite := false
+	for _, item := range projection.Items {
+		p.selectedList = append(p.selectedList, item.Name())
+		path, ok := item.Expr.(*partiql.Path)
+		if !ok {
+			paths = append(paths, nil)
+			continue
+		}
+		paths = append(paths, path)
+		rewrite = rewrite || item.Alias != "" || len(path.Steps) > 0
+		if _, ok := seen[path.Root]; !ok {
+			seen[path.Root] = struct{}{}
+			roots = append(roots, p.rootSourceOf(path))
+		}
+	}
+	if !rewrite {
+		return nil
+	}
+	p.selectedPaths = paths
+	return []partiql.Edit{{Span: projection.Span, Text: strings.Join(roots, ", ")}}
+}
+
+// rootSourceOf returns the source text of the top-level attribute of the path, quoted as written.
+func (p *queryPlan) rootSourceOf(path *partiql.Path) string {
+	end := path.EndPos().Offset
+	if len(path.Steps) > 0 {
+		end = path.Steps[0].Pos().Offset
+	}
+	return strings.TrimSpace(p.query.Source[path.Pos().Offset:end])
+}
+
+// describeTableTargetFromWhere extracts the target table from `WHERE table_name = ?|'name'`
+func describeTableTargetFromWhere(stmt *partiql.SelectStatement) (string, error) {
+	invalid := func() error {
+		return newSyntaxError(
+			stmt.Pos(), `"%s" requires "WHERE table_name = ?" or "WHERE table_name = '<table name>'"`,
+			metaTableDescribeTable,
+		)
+	}
+	cond, ok := stmt.Where.(*partiql.BinaryExpr)
+	if !ok || cond.Op != "=" {
+		return "", invalid()
+	}
+	path, ok := cond.X.(*partiql.Path)
+	if !ok || path.String() != "table_name" {
+		return "", invalid()
+	}
+	switch v := cond.Y.(type) {
+	case *partiql.Placeholder:
+		return "?", nil
+	case *partiql.Literal:
+		if v.Kind == partiql.StringLiteral {
+			return v.Value, nil
+		}
+	}
+	return "", invalid()
+}
+
+// toSyntaxError converts the error returned by the parser to *SyntaxError
+func toSyntaxError(err error) error {
+	var perr *partiql.Error
+	if errors.As(err, &perr) {
+		return &SyntaxError{Line: perr.Pos.Line, Column: perr.Pos.Column, Msg: perr.Msg}
+	}
+	return err
+}
+
+// newSyntaxError returns a new *SyntaxError at the given position
+func newSyntaxError(pos partiql.Position, format string, args ...any) *SyntaxError {
+	return &SyntaxError{Line: pos.Line, Column: pos.Column, Msg: fmt.Sprintf(format, args...)}
+}
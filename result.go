@@ -1,16 +1,25 @@
 package pqxd
 
-import "database/sql/driver"
+import (
+	"database/sql/driver"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
 
 // compatibility checks
 var (
-	_ driver.Result = (*pqxdResult)(nil)
+	_ driver.Result          = (*pqxdResult)(nil)
+	_ ResultConsumedCapacity = (*pqxdResult)(nil)
+	_ ResultConsumedCapacity = (*lazyResult)(nil)
 )
 
 // pqxdResult is an implementation of driver.Result
 type pqxdResult struct {
 	// affected is the number of rows affected
 	affected int64
+
+	// capacity records the consumed capacity. nil if not recorded.
+	capacity *capacityRecorder
 }
 
 // LastInsertId See: driver.Result
@@ -23,6 +32,11 @@ func (r pqxdResult) RowsAffected() (int64, error) {
 	return r.affected, nil
 }
 
+// ConsumedCapacity See: ResultConsumedCapacity
+func (r pqxdResult) ConsumedCapacity() []types.ConsumedCapacity {
+	return r.capacity.consumedCapacity()
+}
+
 // newPqxdResult returns new pqxdResult
 func newPqxdResult(affected int64) *pqxdResult {
 	return &pqxdResult{affected: affected}
@@ -31,6 +45,9 @@ func newPqxdResult(affected int64) *pqxdResult {
 // lazyResult is resolve the affected rows lazily
 type lazyResult struct {
 	getAffected func() (int64, error)
+
+	// capacity records the consumed capacity. nil if not recorded.
+	capacity *capacityRecorder
 }
 
 // LastInsertId See: driver.Result
@@ -43,7 +60,12 @@ func (r lazyResult) RowsAffected() (int64, error) {
 	return r.getAffected()
 }
 
+// ConsumedCapacity See: ResultConsumedCapacity
+func (r lazyResult) ConsumedCapacity() []types.ConsumedCapacity {
+	return r.capacity.consumedCapacity()
+}
+
 // newLazyResult returns new lazyResult
-func newLazyResult(getAffected func() (int64, error)) driver.Result {
-	return lazyResult{getAffected: getAffected}
+func newLazyResult(getAffected func() (int64, error), capacity *capacityRecorder) driver.Result {
+	return lazyResult{getAffected: getAffected, capacity: capacity}
 }
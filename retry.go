@@ -0,0 +1,84 @@
+package pqxd
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/aws/retry"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// RetryPolicy is the policy for retrying a request to DynamoDB.
+type RetryPolicy struct {
+	// MaxAttempts is the maximum number of attempts, including the first one. 1 or less disables retries.
+	MaxAttempts int
+
+	// BaseDelay is the delay before the first retry. it doubles on each retry.
+	BaseDelay time.Duration
+
+	// MaxDelay is the upper limit of the delay between retries.
+	MaxDelay time.Duration
+}
+
+// DefaultTxRetryPolicy is the default RetryPolicy for committing a transaction.
+var DefaultTxRetryPolicy = RetryPolicy{
+	MaxAttempts: 3,
+	BaseDelay:   100 * time.Millisecond,
+	MaxDelay:    2 * time.Second,
+}
+
+// delay returns the delay before the next attempt.
+func (p RetryPolicy) delay(attempt int) time.Duration {
+	delay := p.BaseDelay << (attempt - 1)
+	if delay <= 0 || delay > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return delay
+}
+
+// wait blocks until the delay before the next attempt elapses or ctx is done.
+func (p RetryPolicy) wait(ctx context.Context, attempt int) error {
+	timer := time.NewTimer(p.delay(attempt))
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// retryableErrors are the transport and throttling errors retried by the AWS SDK by default.
+var retryableErrors = retry.IsErrorRetryables(retry.DefaultRetryables)
+
+// retryableCancellationReasons are the cancellation reasons of a transaction that may succeed on retry.
+var retryableCancellationReasons = map[string]struct{}{
+	"None":                          {},
+	"TransactionConflict":           {},
+	"ThrottlingError":               {},
+	"ProvisionedThroughputExceeded": {},
+	"RequestLimitExceeded":          {},
+}
+
+// isRetryableTxError reports whether the commit of a transaction failed with err can be retried.
+func isRetryableTxError(err error) bool {
+	var canceled *types.TransactionCanceledException
+	if errors.As(err, &canceled) {
+		if len(canceled.CancellationReasons) == 0 {
+			return false
+		}
+		for _, reason := range canceled.CancellationReasons {
+			if _, ok := retryableCancellationReasons[aws.ToString(reason.Code)]; !ok {
+				return false
+			}
+		}
+		return true
+	}
+	var conflict *types.TransactionConflictException
+	if errors.As(err, &conflict) {
+		return true
+	}
+	return retryableErrors.IsErrorRetryable(err) == aws.TrueTernary
+}
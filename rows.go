@@ -8,8 +8,9 @@ import (
 	"slices"
 	"sync"
 
-	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/aws"
 	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/miyamo2/pqxd/internal/partiql"
 	"go.uber.org/atomic"
 )
 
@@ -17,16 +18,64 @@ import (
 var (
 	_ driver.Rows              = (*pqxdRows)(nil)
 	_ driver.RowsNextResultSet = (*pqxdRows)(nil)
+	_ RowsNextToken            = (*pqxdRows)(nil)
+	_ RowsConsumedCapacity     = (*pqxdRows)(nil)
 )
 
+// RowsNextToken is an extension of driver.Rows for resuming the pagination.
+// driver.Rows returned from the connection obtained by sql.Conn.Raw implements it.
+type RowsNextToken interface {
+	driver.Rows
+
+	// NextToken returns the token to fetch the page after the current result set. empty if no more pages.
+	// The token can be given to WithNextToken to resume the pagination.
+	NextToken() string
+}
+
 // fetchClosure fetches the next result set.
 type fetchClosure func(ctx context.Context, nextToken *string, dest *[]map[string]types.AttributeValue) (*string, error)
 
+// newLimitFetchClosure returns fetchClosure which skips the first offset items,
+// and stops fetching once count items are produced.
+func newLimitFetchClosure(fetch fetchClosure, count, offset int64) fetchClosure {
+	return func(ctx context.Context, nextToken *string, dest *[]map[string]types.AttributeValue) (*string, error) {
+		for {
+			if count <= 0 {
+				*dest = nil
+				return nil, nil
+			}
+			var items []map[string]types.AttributeValue
+			nt, err := fetch(ctx, nextToken, &items)
+			if err != nil {
+				return nil, err
+			}
+			skip := min(offset, int64(len(items)))
+			offset -= skip
+			items = items[skip:]
+			if skip > 0 && len(items) == 0 && nt != nil {
+				// the whole page is skipped. fetch the next page.
+				nextToken = nt
+				continue
+			}
+			if int64(len(items)) >= count {
+				items = items[:count]
+				nt = nil
+			}
+			count -= int64(len(items))
+			*dest = items
+			return nt, nil
+		}
+	}
+}
+
 // pqxdRows is an implementation of driver.Rows
 type pqxdRows struct {
 	// columnNames is the list of column names.
 	columnNames []string
 
+	// columnPaths are the document paths of the columns. nil if every column is the top-level attribute of its name.
+	columnPaths []*partiql.Path
+
 	// nextToken See: https://pkg.go.dev/github.com/aws/aws-sdk-go-v2/service/dynamodb#ExecuteStatementInput
 	nextToken *atomic.Pointer[string]
 
@@ -41,6 +90,33 @@ type pqxdRows struct {
 
 	// outCursor is the current cursor position in the result set.
 	outCursor *atomic.Uint32
+
+	// encodeNextToken encodes nextToken exposed to callers. nil if exposed as is.
+	encodeNextToken func(token *string) string
+
+	// cursor receives the NextToken. nil if not specified.
+	cursor *Cursor
+
+	// capacity records the consumed capacity. nil if not recorded.
+	capacity *capacityRecorder
+
+	// columnSchema returns the schema of the columns known from the target table. nil if unknown.
+	columnSchema func() *columnSchema
+
+	// numberMode is the Go type of N attributes
+	numberMode NumberMode
+
+	// typeRegistry decodes the attribute values before numberMode. nil if none is registered.
+	typeRegistry *typeRegistry
+
+	// attributeValues if true, Next returns the attribute values as they are. See: WithAttributeValues
+	attributeValues bool
+
+	// timeEncoding is the encoding of the columns scanned into time.Time. See: WithTimeEncoding
+	timeEncoding TimeEncoding
+
+	// row is the current row read by NextRow.
+	row []driver.Value
 }
 
 // Next See: driver.Rows
@@ -54,14 +130,14 @@ func (r *pqxdRows) Next(dest []driver.Value) error {
 	row := out[cursor]
 	r.outCursor.Store(r.outCursor.Inc())
 
-	for i, col := range r.columnNames {
-		var value any
-		colVal, ok := row[col]
+	for i := range r.columnNames {
+		colVal, ok := r.attribute(row, i)
 		if !ok {
 			dest[i] = nil
 			continue
 		}
-		if err := attributevalue.Unmarshal(colVal, &value); err != nil {
+		value, err := r.decode(colVal)
+		if err != nil {
 			return err
 		}
 		dest[i] = value
@@ -69,6 +145,51 @@ func (r *pqxdRows) Next(dest []driver.Value) error {
 	return nil
 }
 
+// attribute returns the attribute value of the column in the item. ok is false if missing.
+func (r *pqxdRows) attribute(item map[string]types.AttributeValue, index int) (av types.AttributeValue, ok bool) {
+	if r.columnPaths == nil || r.columnPaths[index] == nil {
+		av, ok = item[r.columnNames[index]]
+		return av, ok
+	}
+	return resolvePath(item, r.columnPaths[index])
+}
+
+// resolvePath returns the attribute value at the document path in the item. ok is false if missing.
+func resolvePath(item map[string]types.AttributeValue, path *partiql.Path) (types.AttributeValue, bool) {
+	av, ok := item[path.Root]
+	for _, step := range path.Steps {
+		if !ok {
+			return nil, false
+		}
+		switch v := av.(type) {
+		case *types.AttributeValueMemberM:
+			if step.Kind != partiql.FieldStep {
+				return nil, false
+			}
+			av, ok = v.Value[step.Name]
+		case *types.AttributeValueMemberL:
+			if step.Kind != partiql.IndexStep || step.Index >= len(v.Value) {
+				return nil, false
+			}
+			av = v.Value[step.Index]
+		default:
+			return nil, false
+		}
+	}
+	return av, ok
+}
+
+// decode decodes the attribute value into the value returned by Next.
+func (r *pqxdRows) decode(av types.AttributeValue) (any, error) {
+	if r.attributeValues {
+		return av, nil
+	}
+	if v, ok, err := r.typeRegistry.decode(av); ok || err != nil {
+		return v, err
+	}
+	return r.numberMode.decode(av)
+}
+
 // HasNextResultSet See: driver.RowsNextResultSet
 func (r *pqxdRows) HasNextResultSet() bool {
 	return r.nextToken.Load() != nil
@@ -97,9 +218,31 @@ func (r *pqxdRows) NextResultSet() error {
 	r.nextToken.Store(nt)
 	r.out.Store(&next)
 	r.outCursor.Store(0)
+	r.storeCursor()
 	return nil
 }
 
+// NextToken See: RowsNextToken
+func (r *pqxdRows) NextToken() string {
+	nt := r.nextToken.Load()
+	if r.encodeNextToken == nil {
+		return aws.ToString(nt)
+	}
+	return r.encodeNextToken(nt)
+}
+
+// ConsumedCapacity See: RowsConsumedCapacity
+func (r *pqxdRows) ConsumedCapacity() []types.ConsumedCapacity {
+	return r.capacity.consumedCapacity()
+}
+
+// storeCursor stores the NextToken into the cursor if specified.
+func (r *pqxdRows) storeCursor() {
+	if r.cursor != nil {
+		r.cursor.store(r.NextToken())
+	}
+}
+
 // Columns See: driver.Rows
 func (r *pqxdRows) Columns() []string {
 	return r.columnNames
@@ -189,6 +332,7 @@ func newTxRows(columnNames []string, fetch fetchClosure, txCommit *txCommit) *tx
 			fetchCancel: atomic.NewPointer[context.CancelFunc](nil),
 			out:         atomic.NewPointer(new([]map[string]types.AttributeValue)),
 			outCursor:   atomic.NewUint32(0),
+			capacity:    txCommit.capacity,
 		},
 		txCommiter: txCommit,
 		once:       sync.Once{},
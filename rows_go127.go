@@ -0,0 +1,48 @@
+//go:build go1.27
+
+package pqxd
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"time"
+)
+
+// compatibility check
+var (
+	_ driver.RowsColumnScanner = (*pqxdRows)(nil)
+	_ driver.RowsColumnScanner = (*txRows)(nil)
+)
+
+// NextRow See: driver.RowsColumnScanner
+func (r *pqxdRows) NextRow() error {
+	if r.row == nil {
+		r.row = make([]driver.Value, len(r.columnNames))
+	}
+	return r.Next(r.row)
+}
+
+// ScanColumn See: driver.RowsColumnScanner
+//
+// The columns scanned into *time.Time are decoded with the encoding set by WithTimeEncoding.
+// The others are converted by database/sql as the values returned by Next.
+func (r *pqxdRows) ScanColumn(scanCtx driver.ScanContext, index int, dest any) error {
+	src := r.row[index]
+	if d, ok := dest.(*time.Time); ok {
+		t, err := r.timeEncoding.decode(src)
+		if err != nil {
+			return err
+		}
+		*d = t
+		return nil
+	}
+	return sql.ConvertAssign(scanCtx, dest, src)
+}
+
+// NextRow See: driver.RowsColumnScanner
+func (r *txRows) NextRow() error {
+	if r.row == nil {
+		r.row = make([]driver.Value, len(r.columnNames))
+	}
+	return r.Next(r.row)
+}
@@ -0,0 +1,218 @@
+package pqxd
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/miyamo2/pqxd/internal/partiql"
+)
+
+// ScanPolicy is the policy for SELECT, UPDATE and DELETE statements that scan a whole table or index.
+type ScanPolicy int
+
+const (
+	// ScanPolicyAllow executes the statements that scan without checking. It is the default.
+	ScanPolicyAllow ScanPolicy = iota
+
+	// ScanPolicyWarn logs a warning with slog and executes the statements that scan.
+	ScanPolicyWarn
+
+	// ScanPolicyReject rejects the statements that scan with ErrScanNotAllowed.
+	ScanPolicyReject
+)
+
+// hintAllowScan is the hint that allows the statement to scan regardless of ScanPolicy.
+const hintAllowScan = "ALLOW_SCAN"
+
+// accessKind is the way DynamoDB reads the items of a statement.
+type accessKind int
+
+const (
+	// accessKeyLookup reads an item by the full primary key of the table.
+	accessKeyLookup accessKind = iota + 1
+
+	// accessQuery reads the items of the partition specified by the partition key.
+	accessQuery
+
+	// accessScan reads the whole table or index.
+	accessScan
+)
+
+// String See: fmt.Stringer
+func (k accessKind) String() string {
+	switch k {
+	case accessKeyLookup:
+		return "KeyLookup"
+	case accessQuery:
+		return "Query"
+	case accessScan:
+		return "Scan"
+	}
+	return ""
+}
+
+// accessPath is how a statement accesses the items of the table or the index.
+type accessPath struct {
+	kind accessKind
+
+	// table is the name of the target table.
+	table string
+
+	// index is the name of the target index. empty if the table itself.
+	index string
+
+	// partitionKey is the partition key of the table or the index. empty if unknown.
+	partitionKey string
+
+	// keyConditions are the conditions on the key attributes as written. e.g. id = ?
+	keyConditions []string
+}
+
+// target returns the quoted name of the target table or index. e.g. "users"."gsi"
+func (a accessPath) target() string {
+	if a.index == "" {
+		return fmt.Sprintf("%q", a.table)
+	}
+	return fmt.Sprintf("%q.%q", a.table, a.index)
+}
+
+// newAccessPath classifies how the statement of the plan accesses the items with the key schema of the table.
+func newAccessPath(plan *queryPlan, table *types.TableDescription) accessPath {
+	ref, where, _ := plan.target()
+	partitionKey, sortKey := keyAttributes(table, ref.Index)
+	path := accessPath{
+		kind:         accessScan,
+		table:        ref.Name,
+		index:        ref.Index,
+		partitionKey: partitionKey,
+	}
+	if partitionKey == "" {
+		return path
+	}
+
+	var (
+		partitionCond, sortCond   partiql.Expr
+		partitionEqual, sortEqual bool
+	)
+	for _, cond := range conjuncts(where) {
+		attr, op := keyCondition(cond)
+		switch {
+		case attr == partitionKey && (op == "=" || op == "IN"):
+			if partitionCond == nil || op == "=" {
+				partitionCond, partitionEqual = cond, op == "="
+			}
+		case attr != "" && attr == sortKey && op != "IN":
+			if sortCond == nil || op == "=" {
+				sortCond, sortEqual = cond, op == "="
+			}
+		}
+	}
+	if partitionCond == nil {
+		return path
+	}
+
+	path.keyConditions = append(path.keyConditions, plan.sourceOf(partitionCond))
+	if sortCond != nil {
+		path.keyConditions = append(path.keyConditions, plan.sourceOf(sortCond))
+	}
+	path.kind = accessQuery
+	if ref.Index == "" && partitionEqual && (sortKey == "" || sortEqual) {
+		path.kind = accessKeyLookup
+	}
+	return path
+}
+
+// conjuncts splits the condition into the operands of the top-level AND.
+func conjuncts(expr partiql.Expr) []partiql.Expr {
+	switch v := expr.(type) {
+	case nil:
+		return nil
+	case *partiql.ParenExpr:
+		return conjuncts(v.X)
+	case *partiql.BinaryExpr:
+		if v.Op == "AND" {
+			return append(conjuncts(v.X), conjuncts(v.Y)...)
+		}
+	}
+	return []partiql.Expr{expr}
+}
+
+// keyCondition returns the top-level attribute and the operator of a condition usable as a key condition.
+// attr is empty if the condition is not.
+func keyCondition(expr partiql.Expr) (attr string, op string) {
+	rootOf := func(expr partiql.Expr) string {
+		if p, ok := expr.(*partiql.Path); ok && len(p.Steps) == 0 {
+			return p.Root
+		}
+		return ""
+	}
+	switch v := expr.(type) {
+	case *partiql.BinaryExpr:
+		switch v.Op {
+		case "=", "<", "<=", ">", ">=":
+		default:
+			return "", ""
+		}
+		x, y := rootOf(v.X), rootOf(v.Y)
+		switch {
+		case x != "" && y == "":
+			return x, v.Op
+		case x == "" && y != "":
+			return y, v.Op
+		}
+	case *partiql.BetweenExpr:
+		if !v.Not {
+			return rootOf(v.X), "BETWEEN"
+		}
+	case *partiql.InExpr:
+		if !v.Not {
+			return rootOf(v.X), "IN"
+		}
+	case *partiql.CallExpr:
+		if strings.EqualFold(v.Name, "begins_with") && len(v.Args) == 2 {
+			return rootOf(v.Args[0]), "BEGINS_WITH"
+		}
+	}
+	return "", ""
+}
+
+// checkScan applies ScanPolicy of the connector to the statement of the plan.
+//
+// The statement is allowed to scan with WithScanAllowed or the hint /*+ ALLOW_SCAN */.
+func (c *connection) checkScan(ctx context.Context, plan *queryPlan) error {
+	policy := c.setting.scanPolicy
+	if policy == ScanPolicyAllow || scanAllowedFromContext(ctx) || plan.query.HasHint(hintAllowScan) {
+		return nil
+	}
+	ref, _, ok := plan.target()
+	if !ok {
+		return nil
+	}
+	table, err := c.setting.schemaCache.describe(ctx, c.client, ref.Name)
+	if err != nil {
+		if policy == ScanPolicyWarn {
+			slog.WarnContext(ctx, "pqxd: failed to describe table for scan detection", "table", ref.Name, "error", err)
+			return nil
+		}
+		return err
+	}
+
+	path := newAccessPath(plan, table)
+	if path.kind != accessScan {
+		return nil
+	}
+	if policy == ScanPolicyWarn {
+		slog.WarnContext(ctx, "pqxd: statement scans the whole table", "target", path.target(), "statement", plan.statement)
+		return nil
+	}
+	if path.partitionKey == "" {
+		return fmt.Errorf("%w: the key schema of %s is unknown", ErrScanNotAllowed, path.target())
+	}
+	return fmt.Errorf(
+		"%w: %s is scanned since WHERE does not specify the partition key %q with = or IN",
+		ErrScanNotAllowed, path.target(), path.partitionKey,
+	)
+}
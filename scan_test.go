@@ -0,0 +1,121 @@
+package pqxd
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+// usersTable is the schema of "users" with a global secondary index "gsi"
+var usersTable = &types.TableDescription{
+	TableName: aws.String("users"),
+	KeySchema: []types.KeySchemaElement{
+		{AttributeName: aws.String("pk"), KeyType: types.KeyTypeHash},
+		{AttributeName: aws.String("sk"), KeyType: types.KeyTypeRange},
+	},
+	GlobalSecondaryIndexes: []types.GlobalSecondaryIndexDescription{
+		{
+			IndexName: aws.String("gsi"),
+			KeySchema: []types.KeySchemaElement{
+				{AttributeName: aws.String("name"), KeyType: types.KeyTypeHash},
+			},
+		},
+	},
+}
+
+func Test_newAccessPath(t *testing.T) {
+	type want struct {
+		kind          accessKind
+		keyConditions []string
+	}
+	type test struct {
+		query string
+		want  want
+	}
+
+	tests := map[string]test{
+		"key-lookup": {
+			query: `SELECT * FROM "users" WHERE pk = ? AND sk = ?`,
+			want:  want{kind: accessKeyLookup, keyConditions: []string{"pk = ?", "sk = ?"}},
+		},
+		"key-lookup-with-update": {
+			query: `UPDATE "users" SET name = ? WHERE ? = pk AND (sk = 'a')`,
+			want:  want{kind: accessKeyLookup, keyConditions: []string{"? = pk", "sk = 'a'"}},
+		},
+		"partition-query": {
+			query: `SELECT * FROM "users" WHERE pk IN [?, ?] AND begins_with(sk, ?)`,
+			want:  want{kind: accessQuery, keyConditions: []string{"pk IN [?, ?]", "begins_with(sk, ?)"}},
+		},
+		"partition-query-on-index": {
+			query: `SELECT * FROM "users"."gsi" WHERE name = ?`,
+			want:  want{kind: accessQuery, keyConditions: []string{"name = ?"}},
+		},
+		"scan-without-partition-key": {
+			query: `SELECT * FROM "users" WHERE name = ?`,
+			want:  want{kind: accessScan},
+		},
+		"scan-with-or": {
+			query: `DELETE FROM "users" WHERE pk = ? OR sk = ?`,
+			want:  want{kind: accessScan},
+		},
+		"scan-with-unknown-index": {
+			query: `SELECT * FROM "users"."unknown" WHERE pk = ?`,
+			want:  want{kind: accessScan},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				plan, err := newQueryPlan(tt.query)
+				if err != nil {
+					t.Fatalf("newQueryPlan() unexpected error = %v", err)
+				}
+				got := newAccessPath(plan, usersTable)
+				if got.kind != tt.want.kind {
+					t.Errorf("newAccessPath().kind = %s, want %s", got.kind, tt.want.kind)
+				}
+				if diff := cmp.Diff(tt.want.keyConditions, got.keyConditions); diff != "" {
+					t.Errorf("newAccessPath().keyConditions mismatch (-want +got):\n%s", diff)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_QueryContext_with_ScanPolicyReject(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+		ThenReturn(&dynamodb.DescribeTableOutput{Table: usersTable}, nil).
+		Verify(Times(1))
+	WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+		ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil).
+		Verify(Times(3))
+
+	sut := newConnection(client, WithScanPolicy(ScanPolicyReject))
+	ctx := context.Background()
+
+	if _, err := sut.QueryContext(ctx, `SELECT * FROM "users" WHERE name = ?`, nil); !errors.Is(err, ErrScanNotAllowed) {
+		t.Errorf("QueryContext() error = %v, want %v", err, ErrScanNotAllowed)
+	}
+	if _, err := sut.ExecContext(ctx, `DELETE FROM "users" WHERE sk = ?`, nil); !errors.Is(err, ErrScanNotAllowed) {
+		t.Errorf("ExecContext() error = %v, want %v", err, ErrScanNotAllowed)
+	}
+	if _, err := sut.QueryContext(ctx, `SELECT * FROM "users" WHERE pk = ?`, nil); err != nil {
+		t.Errorf("QueryContext() unexpected error = %v", err)
+	}
+	if _, err := sut.QueryContext(WithScanAllowed(ctx), `SELECT * FROM "users"`, nil); err != nil {
+		t.Errorf("QueryContext() with WithScanAllowed unexpected error = %v", err)
+	}
+	if _, err := sut.QueryContext(ctx, `SELECT /*+ ALLOW_SCAN */ * FROM "users"`, nil); err != nil {
+		t.Errorf("QueryContext() with hint unexpected error = %v", err)
+	}
+}
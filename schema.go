@@ -0,0 +1,184 @@
+package pqxd
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// DefaultSchemaCacheTTL is the default time to live of the table schemas cached in the connector.
+const DefaultSchemaCacheTTL = 5 * time.Minute
+
+// tableSchemaCache caches the TableDescription returned by DescribeTable API.
+// It is shared by all connections of the connector.
+type tableSchemaCache struct {
+	// ttl is the time to live of the cached schemas. zero or less disables caching.
+	ttl time.Duration
+
+	mu sync.Mutex
+
+	// tables are the cached schemas by table name
+	tables map[string]tableSchemaEntry
+
+	// calls are the in-flight DescribeTable API calls by table name
+	calls map[string]*tableSchemaCall
+}
+
+// tableSchemaEntry is a cached schema.
+type tableSchemaEntry struct {
+	table     *types.TableDescription
+	expiresAt time.Time
+}
+
+// tableSchemaCall is an in-flight DescribeTable API call, shared by concurrent misses.
+type tableSchemaCall struct {
+	done  chan struct{}
+	table *types.TableDescription
+	err   error
+}
+
+// newTableSchemaCache returns a new tableSchemaCache.
+func newTableSchemaCache(ttl time.Duration) *tableSchemaCache {
+	return &tableSchemaCache{
+		ttl:    ttl,
+		tables: make(map[string]tableSchemaEntry),
+		calls:  make(map[string]*tableSchemaCall),
+	}
+}
+
+// describe returns the TableDescription of the table, calling DescribeTable API on a cache miss.
+// Concurrent misses of the same table wait for a single call.
+//
+// If the call fails because the context of the caller is done, the misses waiting for it call DescribeTable API again,
+// so that the cancellation of one statement does not fail the others.
+func (c *tableSchemaCache) describe(
+	ctx context.Context, client DynamoDBClient, name string,
+) (*types.TableDescription, error) {
+	c.mu.Lock()
+	if entry, ok := c.tables[name]; ok && time.Now().Before(entry.expiresAt) {
+		c.mu.Unlock()
+		return entry.table, nil
+	}
+	call, inflight := c.calls[name]
+	if !inflight {
+		call = &tableSchemaCall{done: make(chan struct{})}
+		c.calls[name] = call
+	}
+	c.mu.Unlock()
+
+	if inflight {
+		select {
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		case <-call.done:
+			if errors.Is(call.err, context.Canceled) || errors.Is(call.err, context.DeadlineExceeded) {
+				return c.describe(ctx, client, name)
+			}
+			return call.table, call.err
+		}
+	}
+
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+	if err == nil && output != nil {
+		call.table = output.Table
+	}
+	call.err = err
+
+	c.mu.Lock()
+	// the result of the call invalidated meanwhile is not cached.
+	if c.calls[name] == call {
+		delete(c.calls, name)
+		if err == nil {
+			c.store(name, call.table)
+		}
+	}
+	c.mu.Unlock()
+	close(call.done)
+	return call.table, call.err
+}
+
+// refresh calls DescribeTable API regardless of the cached schema, and caches the result.
+// It is used where the volatile fields, such as TableStatus and ItemCount, must be up to date.
+func (c *tableSchemaCache) refresh(
+	ctx context.Context, client DynamoDBClient, name string,
+) (*types.TableDescription, error) {
+	output, err := client.DescribeTable(ctx, &dynamodb.DescribeTableInput{TableName: aws.String(name)})
+	if err != nil {
+		return nil, err
+	}
+	var table *types.TableDescription
+	if output != nil {
+		table = output.Table
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.store(name, table)
+	return table, nil
+}
+
+// store caches the schema of the table. The caller must hold mu.
+// nil is not cached, so that the table is described again.
+func (c *tableSchemaCache) store(name string, table *types.TableDescription) {
+	if table == nil || c.ttl <= 0 {
+		return
+	}
+	c.tables[name] = tableSchemaEntry{table: table, expiresAt: time.Now().Add(c.ttl)}
+}
+
+// invalidate removes the cached schema of the table.
+func (c *tableSchemaCache) invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.tables, name)
+	delete(c.calls, name)
+}
+
+// keyAttributes returns the partition key and the sort key of the table or the index.
+// Empty if not found.
+func keyAttributes(table *types.TableDescription, index string) (partitionKey string, sortKey string) {
+	if table == nil {
+		return "", ""
+	}
+	keySchema := table.KeySchema
+	if index != "" {
+		keySchema = nil
+		for _, v := range table.GlobalSecondaryIndexes {
+			if aws.ToString(v.IndexName) == index {
+				keySchema = v.KeySchema
+			}
+		}
+		for _, v := range table.LocalSecondaryIndexes {
+			if aws.ToString(v.IndexName) == index {
+				keySchema = v.KeySchema
+			}
+		}
+	}
+	for _, v := range keySchema {
+		switch v.KeyType {
+		case types.KeyTypeHash:
+			partitionKey = aws.ToString(v.AttributeName)
+		case types.KeyTypeRange:
+			sortKey = aws.ToString(v.AttributeName)
+		}
+	}
+	return partitionKey, sortKey
+}
+
+// isLocalSecondaryIndex reports whether the index is a local secondary index of the table.
+func isLocalSecondaryIndex(table *types.TableDescription, index string) bool {
+	if table == nil {
+		return false
+	}
+	for _, v := range table.LocalSecondaryIndexes {
+		if aws.ToString(v.IndexName) == index {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,192 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+	"go.uber.org/atomic"
+)
+
+func Test_tableSchemaCache_describe_concurrent_misses(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	release := make(chan struct{})
+	calls := atomic.NewInt32(0)
+	WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+		ThenAnswer(
+			func(args []any) (*dynamodb.DescribeTableOutput, error) {
+				calls.Inc()
+				<-release
+				return &dynamodb.DescribeTableOutput{Table: usersTable}, nil
+			},
+		)
+
+	// without caching, only the misses waiting for the in-flight call share it.
+	sut := newTableSchemaCache(0)
+	var wg sync.WaitGroup
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			table, err := sut.describe(context.Background(), client, "users")
+			if err != nil {
+				t.Errorf("describe() unexpected error = %v", err)
+			}
+			if table != usersTable {
+				t.Errorf("describe() = %v, want %v", table, usersTable)
+			}
+		}()
+	}
+	time.Sleep(100 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	if got := calls.Load(); got != 1 {
+		t.Errorf("DescribeTable calls = %d, want 1", got)
+	}
+}
+
+func Test_tableSchemaCache_describe_canceled_by_first_miss(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	started := make(chan struct{})
+	calls := atomic.NewInt32(0)
+	WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+		ThenAnswer(
+			func(args []any) (*dynamodb.DescribeTableOutput, error) {
+				if calls.Inc() == 1 {
+					ctx := args[0].(context.Context)
+					close(started)
+					<-ctx.Done()
+					return nil, ctx.Err()
+				}
+				return &dynamodb.DescribeTableOutput{Table: usersTable}, nil
+			},
+		)
+
+	sut := newTableSchemaCache(DefaultSchemaCacheTTL)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		if _, err := sut.describe(ctx, client, "users"); !errors.Is(err, context.Canceled) {
+			t.Errorf("describe() error = %v, want %v", err, context.Canceled)
+		}
+	}()
+	<-started
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		table, err := sut.describe(context.Background(), client, "users")
+		if err != nil {
+			t.Errorf("describe() unexpected error = %v", err)
+		}
+		if table != usersTable {
+			t.Errorf("describe() = %v, want %v", table, usersTable)
+		}
+	}()
+	time.Sleep(100 * time.Millisecond)
+	cancel()
+	<-done
+
+	if got := calls.Load(); got != 2 {
+		t.Errorf("DescribeTable calls = %d, want 2", got)
+	}
+}
+
+func Test_tableSchemaCache_refresh_without_table(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+		ThenReturn(&dynamodb.DescribeTableOutput{}, nil).
+		ThenReturn(&dynamodb.DescribeTableOutput{Table: usersTable}, nil).
+		Verify(Times(2))
+
+	sut := newTableSchemaCache(DefaultSchemaCacheTTL)
+	ctx := context.Background()
+	if _, err := sut.refresh(ctx, client, "users"); err != nil {
+		t.Fatalf("refresh() unexpected error = %v", err)
+	}
+	// nil is not cached, so the table is described again.
+	table, err := sut.describe(ctx, client, "users")
+	if err != nil {
+		t.Fatalf("describe() unexpected error = %v", err)
+	}
+	if table != usersTable {
+		t.Errorf("describe() = %v, want %v", table, usersTable)
+	}
+}
+
+func Test_Connection_schema_cache_invalidated_by_DDL(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+		ThenReturn(&dynamodb.DescribeTableOutput{Table: usersTable}, nil).
+		Verify(Times(2))
+	WhenDouble(client.DeleteTable(AnyContext(), Any[*dynamodb.DeleteTableInput]())).
+		ThenReturn(&dynamodb.DeleteTableOutput{}, nil)
+
+	sut := newConnection(client)
+	ctx := context.Background()
+	query := `EXPLAIN SELECT * FROM "users" WHERE id = '1'`
+
+	for range 2 {
+		if _, err := sut.QueryContext(ctx, query, nil); err != nil {
+			t.Fatalf("QueryContext() unexpected error = %v", err)
+		}
+	}
+	if _, err := sut.ExecContext(ctx, `DROP TABLE "users"`, nil); err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+	if _, err := sut.QueryContext(ctx, query, nil); err != nil {
+		t.Fatalf("QueryContext() unexpected error = %v", err)
+	}
+}
+
+func Test_Connection_describeTable_refreshes_cache(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	table := func(status types.TableStatus) *types.TableDescription {
+		v := *usersTable
+		v.TableStatus = status
+		return &v
+	}
+	WhenDouble(client.DescribeTable(AnyContext(), Any[*dynamodb.DescribeTableInput]())).
+		ThenReturn(&dynamodb.DescribeTableOutput{Table: table(types.TableStatusCreating)}, nil).
+		ThenReturn(&dynamodb.DescribeTableOutput{Table: table(types.TableStatusActive)}, nil).
+		Verify(Times(2))
+
+	sut := newConnection(client)
+	ctx := context.Background()
+	query := `SELECT TableStatus FROM "!pqxd_describe_table" WHERE table_name = 'users'`
+
+	for _, want := range []types.TableStatus{types.TableStatusCreating, types.TableStatusActive} {
+		rows, err := sut.QueryContext(ctx, query, nil)
+		if err != nil {
+			t.Fatalf("QueryContext() unexpected error = %v", err)
+		}
+		dest := make([]driver.Value, 1)
+		if err := rows.Next(dest); err != nil {
+			t.Fatalf("Next() unexpected error = %v", err)
+		}
+		if dest[0] != want {
+			t.Errorf("TableStatus = %v, want %v", dest[0], want)
+		}
+	}
+
+	// the refreshed schema is used to plan the statements without calling DescribeTable.
+	if _, err := sut.QueryContext(ctx, `EXPLAIN SELECT * FROM "users" WHERE id = '1'`, nil); err != nil {
+		t.Fatalf("QueryContext() unexpected error = %v", err)
+	}
+}
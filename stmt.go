@@ -14,21 +14,15 @@ var (
 )
 
 // queryWithPrepare executes the prepared statement
-type queryWithPrepare func(ctx context.Context, query string, selectedList []string, args []driver.NamedValue) (driver.Rows, error)
+type queryWithPrepare func(ctx context.Context, plan *queryPlan, args []driver.NamedValue) (driver.Rows, error)
 
 // execContext executes the prepared statement
-type execContext func(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error)
+type execContext func(ctx context.Context, plan *queryPlan, args []driver.NamedValue) (driver.Result, error)
 
 // statement is an implementation of driver.Stmt
 type statement struct {
-	// query is a string of prepared statement
-	query string
-
-	// selectedList is a list of selected items
-	selectedList []string
-
-	// numInput is the number of placeholders in the statement
-	numInput int
+	// plan is the analyzed query of prepared statement
+	plan *queryPlan
 
 	// queryWithPrepare executes the statement
 	queryWithPrepare queryWithPrepare
@@ -58,7 +52,7 @@ func (s statement) Close() error {
 
 // NumInput See: driver.Stmt
 func (s statement) NumInput() int {
-	return s.numInput
+	return s.plan.numInput
 }
 
 // Exec See: driver.Stmt
@@ -73,7 +67,7 @@ func (s statement) Query(args []driver.Value) (driver.Rows, error) {
 
 // QueryContext See: driver.StmtQueryContext
 func (s statement) QueryContext(ctx context.Context, args []driver.NamedValue) (driver.Rows, error) {
-	return s.queryWithPrepare(ctx, s.query, s.selectedList, args)
+	return s.queryWithPrepare(ctx, s.plan, args)
 }
 
 // ExecContext See: driver.StmtExecContext
@@ -85,22 +79,18 @@ func (s statement) ExecContext(ctx context.Context, args []driver.NamedValue) (d
 		s.closed.Store(true)
 		return nil, ErrStatementClosed
 	}
-	return s.execContext(ctx, s.query, args)
+	return s.execContext(ctx, s.plan, args)
 }
 
 // newStatement returns a new statement
 func newStatement(
-	query string,
-	selectedList []string,
-	numInput int,
+	plan *queryPlan,
 	queryWithPrepare queryWithPrepare,
 	execContext execContext,
 	connCloseCheckClosure func() error,
 ) *statement {
 	return &statement{
-		query:                 query,
-		selectedList:          selectedList,
-		numInput:              numInput,
+		plan:                  plan,
 		queryWithPrepare:      queryWithPrepare,
 		execContext:           execContext,
 		closed:                *atomic.NewBool(false),
@@ -0,0 +1,117 @@
+package pqxd
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// compatibility check
+var (
+	_ driver.Valuer   = item{}
+	_ attributeValuer = item{}
+)
+
+// ScanStruct copies the columns in the current row into the fields of the struct pointed to by dst.
+//
+// The columns are mapped to the fields with the same rules as attributevalue.Unmarshal, including `dynamodbav` tags.
+// The fields of the columns missing in the item are set to the zero value.
+//
+// The rows of the query executed with the context of WithAttributeValues are decoded from the attribute values
+// as they are, without loss of precision. The others are decoded from the values returned following NumberMode,
+// so N attributes may be rounded with NumberModeFloat64 and can be scanned only into string fields with NumberModeString.
+//
+// Example:
+//
+//	type User struct {
+//		ID   string `dynamodbav:"id"`
+//		Name string `dynamodbav:"name"`
+//	}
+//
+//	var user User
+//	err := pqxd.ScanStruct(rows, &user)
+func ScanStruct(rows *sql.Rows, dst any) error {
+	columns, err := rows.Columns()
+	if err != nil {
+		return err
+	}
+	values := make([]any, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return err
+	}
+	item := make(map[string]types.AttributeValue, len(columns))
+	for i, column := range columns {
+		av, ok := values[i].(types.AttributeValue)
+		if !ok {
+			if av, err = driverValueToAttributeValue(values[i]); err != nil {
+				return err
+			}
+		}
+		item[column] = av
+	}
+	return attributevalue.UnmarshalMap(item, dst)
+}
+
+// ScanAll reads all the remaining rows, following the next tokens, into a slice of T with ScanStruct, and closes rows.
+//
+// Example:
+//
+//	rows, err := db.QueryContext(pqxd.WithAttributeValues(ctx), `SELECT id, name FROM "users"`)
+//	if err != nil {
+//		return err
+//	}
+//	users, err := pqxd.ScanAll[User](rows)
+func ScanAll[T any](rows *sql.Rows) ([]T, error) {
+	defer rows.Close()
+	var all []T
+	for rows.NextResultSet() {
+		for rows.Next() {
+			var v T
+			if err := ScanStruct(rows, &v); err != nil {
+				return nil, err
+			}
+			all = append(all, v)
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return all, nil
+}
+
+// item is driver.Valuer that sends a struct or a map as M attribute.
+type item struct {
+	v any
+}
+
+// Item returns driver.Valuer that binds v as a whole item, for `INSERT INTO ... VALUE ?`.
+//
+// v is marshalled with the same rules as attributevalue.MarshalMap, including `dynamodbav` tags.
+//
+// Example:
+//
+//	_, err := db.ExecContext(ctx, `INSERT INTO "users" VALUE ?`, pqxd.Item(user))
+func Item(v any) driver.Valuer {
+	return item{v: v}
+}
+
+// attributeValue See: attributeValuer
+func (i item) attributeValue() (types.AttributeValue, error) {
+	m, err := attributevalue.MarshalMap(i.v)
+	if err != nil {
+		return nil, fmt.Errorf("pqxd: failed to marshal item: %w", err)
+	}
+	return &types.AttributeValueMemberM{Value: m}, nil
+}
+
+// Value implements the driver.Valuer interface.
+func (i item) Value() (driver.Value, error) {
+	return i.attributeValue()
+}
@@ -0,0 +1,152 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	"github.com/google/go-cmp/cmp"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+type testUser struct {
+	ID     string    `dynamodbav:"id"`
+	Name   string    `dynamodbav:"name,omitempty"`
+	Age    int       `dynamodbav:"age"`
+	Tags   StringSet `dynamodbav:"tags"`
+	Ignore string    `dynamodbav:"-"`
+}
+
+func TestScanAll(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{
+					{
+						"id":   &types.AttributeValueMemberS{Value: "1"},
+						"name": &types.AttributeValueMemberS{Value: "Alice"},
+						"age":  &types.AttributeValueMemberN{Value: "20"},
+						"tags": &types.AttributeValueMemberSS{Value: []string{"a", "b"}},
+					},
+				},
+				NextToken: aws.String("token"),
+			}, nil,
+		).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{
+					{
+						"id":  &types.AttributeValueMemberS{Value: "2"},
+						"age": &types.AttributeValueMemberN{Value: "30"},
+					},
+				},
+			}, nil,
+		).
+		Verify(Times(2))
+
+	db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client)))
+	defer db.Close()
+	rows, err := db.QueryContext(context.Background(), `SELECT id, name, age, tags FROM "users"`)
+	if err != nil {
+		t.Fatalf("QueryContext() unexpected error = %v", err)
+	}
+	got, err := ScanAll[testUser](rows)
+	if err != nil {
+		t.Fatalf("ScanAll() unexpected error = %v", err)
+	}
+	want := []testUser{
+		{ID: "1", Name: "Alice", Age: 20, Tags: StringSet{"a", "b"}},
+		{ID: "2", Age: 30},
+	}
+	if diff := cmp.Diff(want, got); diff != "" {
+		t.Errorf("ScanAll() mismatch (-want +got):\n%s", diff)
+	}
+}
+
+func TestItem(t *testing.T) {
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	input := &dynamodb.ExecuteStatementInput{
+		Statement: aws.String(`INSERT INTO "users" VALUE ?`),
+		Parameters: []types.AttributeValue{
+			&types.AttributeValueMemberM{
+				Value: map[string]types.AttributeValue{
+					"id":   &types.AttributeValueMemberS{Value: "1"},
+					"age":  &types.AttributeValueMemberN{Value: "20"},
+					"tags": &types.AttributeValueMemberSS{Value: []string{"a"}},
+				},
+			},
+		},
+	}
+	WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+		ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil).
+		Verify(Times(1))
+
+	db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client)))
+	defer db.Close()
+	user := testUser{ID: "1", Age: 20, Tags: StringSet{"a"}, Ignore: "ignored"}
+	if _, err := db.ExecContext(context.Background(), *input.Statement, Item(user)); err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+}
+
+func TestScanStruct_with_AttributeValues(t *testing.T) {
+	type account struct {
+		ID      int64   `dynamodbav:"id"`
+		Balance float64 `dynamodbav:"balance"`
+	}
+	type test struct {
+		mode NumberMode
+	}
+	tests := map[string]test{
+		"float64": {
+			mode: NumberModeFloat64,
+		},
+		"string": {
+			mode: NumberModeString,
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+
+				WhenDouble(client.ExecuteStatement(AnyContext(), Any[*dynamodb.ExecuteStatementInput]())).
+					ThenReturn(
+						&dynamodb.ExecuteStatementOutput{
+							Items: []map[string]types.AttributeValue{
+								{
+									"id":      &types.AttributeValueMemberN{Value: "9007199254740993"},
+									"balance": &types.AttributeValueMemberN{Value: "1.5"},
+								},
+							},
+						}, nil,
+					).
+					Verify(Times(1))
+
+				db := sql.OpenDB(NewConnector(aws.Config{}, WithDynamoDBClient(client), WithNumberMode(tt.mode)))
+				defer db.Close()
+				rows, err := db.QueryContext(WithAttributeValues(context.Background()), `SELECT id, balance FROM "accounts"`)
+				if err != nil {
+					t.Fatalf("QueryContext() unexpected error = %v", err)
+				}
+				got, err := ScanAll[account](rows)
+				if err != nil {
+					t.Fatalf("ScanAll() unexpected error = %v", err)
+				}
+				want := []account{{ID: 9007199254740993, Balance: 1.5}}
+				if diff := cmp.Diff(want, got); diff != "" {
+					t.Errorf("ScanAll() mismatch (-want +got):\n%s", diff)
+				}
+			},
+		)
+	}
+}
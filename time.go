@@ -0,0 +1,243 @@
+package pqxd
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/feature/dynamodb/attributevalue"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+)
+
+// compatibility check
+var (
+	_ sql.Scanner   = (*EpochTime)(nil)
+	_ driver.Valuer = EpochTime{}
+	_ sql.Scanner   = (*EpochMillis)(nil)
+	_ driver.Valuer = EpochMillis{}
+	_ sql.Scanner   = (*RFC3339Time)(nil)
+	_ driver.Valuer = RFC3339Time{}
+
+	_ attributevalue.Marshaler   = EpochTime{}
+	_ attributevalue.Unmarshaler = (*EpochTime)(nil)
+	_ attributevalue.Marshaler   = EpochMillis{}
+	_ attributevalue.Unmarshaler = (*EpochMillis)(nil)
+	_ attributevalue.Marshaler   = RFC3339Time{}
+	_ attributevalue.Unmarshaler = (*RFC3339Time)(nil)
+)
+
+// TimeEncoding is the encoding of time in attribute values.
+type TimeEncoding int
+
+const (
+	// TimeEncodingRFC3339 encodes time as S attribute in RFC3339 with nanoseconds. It is the default.
+	TimeEncodingRFC3339 TimeEncoding = iota
+
+	// TimeEncodingEpochSeconds encodes time as N attribute in seconds since the Unix epoch, as TTL of DynamoDB requires.
+	TimeEncodingEpochSeconds
+
+	// TimeEncodingEpochMillis encodes time as N attribute in milliseconds since the Unix epoch.
+	TimeEncodingEpochMillis
+)
+
+// encode encodes the time into the attribute value.
+func (e TimeEncoding) encode(t time.Time) types.AttributeValue {
+	switch e {
+	case TimeEncodingEpochSeconds:
+		return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.Unix(), 10)}
+	case TimeEncodingEpochMillis:
+		return &types.AttributeValueMemberN{Value: strconv.FormatInt(t.UnixMilli(), 10)}
+	}
+	return &types.AttributeValueMemberS{Value: t.Format(time.RFC3339Nano)}
+}
+
+// decode converts the value returned by driver.Rows.Next into time.
+//
+// Numbers, including numeric strings returned with NumberModeString, are in milliseconds with TimeEncodingEpochMillis,
+// and in seconds otherwise. The other strings are in RFC3339. NULL is the zero time.
+func (e TimeEncoding) decode(src any) (time.Time, error) {
+	switch v := src.(type) {
+	case nil:
+		return time.Time{}, nil
+	case time.Time:
+		return v, nil
+	case []byte:
+		return e.decode(string(v))
+	case string:
+		if _, err := ParseDecimal(v); err != nil {
+			t, err := time.Parse(time.RFC3339Nano, v)
+			if err != nil {
+				return time.Time{}, fmt.Errorf("pqxd: converting %q to time: %w", v, err)
+			}
+			return t, nil
+		}
+	}
+
+	d, err := decimalFromValue(src)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("pqxd: converting %T to time is unsupported", src)
+	}
+	n, err := d.Int64()
+	switch {
+	case err == nil && e == TimeEncodingEpochMillis:
+		return time.UnixMilli(n), nil
+	case err == nil:
+		return time.Unix(n, 0), nil
+	case !errors.Is(err, ErrInexactNumber):
+		return time.Time{}, err
+	}
+	f, _ := d.BigFloat().Float64()
+	if e == TimeEncodingEpochMillis {
+		f /= 1e3
+	}
+	sec, frac := math.Modf(f)
+	return time.Unix(int64(sec), int64(frac*1e9)), nil
+}
+
+// EpochTime is time sent as N attribute in seconds since the Unix epoch, such as TTL of DynamoDB.
+//
+// It is scanned from N attributes in seconds and S attributes in RFC3339. The zero time is sent as NULL.
+type EpochTime struct {
+	time.Time
+}
+
+// attributeValue See: attributeValuer
+func (t EpochTime) attributeValue() (types.AttributeValue, error) {
+	return encodeTime(t.Time, TimeEncodingEpochSeconds), nil
+}
+
+// Value implements the driver.Valuer interface.
+func (t EpochTime) Value() (driver.Value, error) {
+	return t.attributeValue()
+}
+
+// Scan implements the sql.Scanner interface.
+func (t *EpochTime) Scan(src any) (err error) {
+	t.Time, err = TimeEncodingEpochSeconds.decode(src)
+	return err
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (t EpochTime) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return t.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (t *EpochTime) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, t)
+}
+
+// EpochMillis is time sent as N attribute in milliseconds since the Unix epoch.
+//
+// It is scanned from N attributes in milliseconds and S attributes in RFC3339. The zero time is sent as NULL.
+type EpochMillis struct {
+	time.Time
+}
+
+// attributeValue See: attributeValuer
+func (t EpochMillis) attributeValue() (types.AttributeValue, error) {
+	return encodeTime(t.Time, TimeEncodingEpochMillis), nil
+}
+
+// Value implements the driver.Valuer interface.
+func (t EpochMillis) Value() (driver.Value, error) {
+	return t.attributeValue()
+}
+
+// Scan implements the sql.Scanner interface.
+func (t *EpochMillis) Scan(src any) (err error) {
+	t.Time, err = TimeEncodingEpochMillis.decode(src)
+	return err
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (t EpochMillis) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return t.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (t *EpochMillis) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, t)
+}
+
+// RFC3339Time is time sent as S attribute in RFC3339 with nanoseconds.
+//
+// It is scanned from S attributes in RFC3339 and N attributes in seconds. The zero time is sent as NULL.
+type RFC3339Time struct {
+	time.Time
+}
+
+// attributeValue See: attributeValuer
+func (t RFC3339Time) attributeValue() (types.AttributeValue, error) {
+	return encodeTime(t.Time, TimeEncodingRFC3339), nil
+}
+
+// Value implements the driver.Valuer interface.
+func (t RFC3339Time) Value() (driver.Value, error) {
+	return t.attributeValue()
+}
+
+// Scan implements the sql.Scanner interface.
+func (t *RFC3339Time) Scan(src any) (err error) {
+	t.Time, err = TimeEncodingRFC3339.decode(src)
+	return err
+}
+
+// MarshalDynamoDBAttributeValue implements the attributevalue.Marshaler interface.
+func (t RFC3339Time) MarshalDynamoDBAttributeValue() (types.AttributeValue, error) {
+	return t.attributeValue()
+}
+
+// UnmarshalDynamoDBAttributeValue implements the attributevalue.Unmarshaler interface.
+func (t *RFC3339Time) UnmarshalDynamoDBAttributeValue(av types.AttributeValue) error {
+	return unmarshalAttributeValue(av, t)
+}
+
+// timeScanner is sql.Scanner for *time.Time
+type timeScanner struct {
+	// dst is the destination of the time.
+	dst *time.Time
+
+	// encoding is the encoding of the attribute values.
+	encoding TimeEncoding
+}
+
+// Time returns sql.Scanner that scans N and S attributes into dst following the encoding.
+//
+// N attributes are in milliseconds with TimeEncodingEpochMillis and in seconds otherwise, and S attributes are in RFC3339.
+// Pass the encoding set by WithTimeEncoding of the connector, so that time.Time parameters are read back as they are written.
+//
+// Before Go 1.27, database/sql does not convert strings and numbers into time.Time,
+// so it should be passed to sql.Rows.Scan instead of dst. Since Go 1.27, dst can be passed as it is
+// and is decoded with the encoding set by WithTimeEncoding.
+// NULL is scanned as the zero time.
+//
+// Example:
+//
+//	var expiresAt time.Time
+//	err := row.Scan(pqxd.Time(&expiresAt, pqxd.TimeEncodingEpochSeconds))
+func Time(dst *time.Time, encoding TimeEncoding) sql.Scanner {
+	return &timeScanner{dst: dst, encoding: encoding}
+}
+
+// Scan implements the sql.Scanner interface.
+func (s *timeScanner) Scan(src any) error {
+	t, err := s.encoding.decode(src)
+	if err != nil {
+		return err
+	}
+	*s.dst = t
+	return nil
+}
+
+// encodeTime encodes the time with the encoding. The zero time is encoded as NULL.
+func encodeTime(t time.Time, encoding TimeEncoding) types.AttributeValue {
+	if t.IsZero() {
+		return &types.AttributeValueMemberNULL{Value: true}
+	}
+	return encoding.encode(t)
+}
@@ -0,0 +1,74 @@
+//go:build go1.27
+
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func Test_DB_Scan_time_with_TimeEncoding(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC)
+	type test struct {
+		options []ConnectorOption
+		written types.AttributeValue
+	}
+	tests := map[string]test{
+		"default": {
+			written: &types.AttributeValueMemberS{Value: "2024-01-02T03:04:05.123Z"},
+		},
+		"epoch-millis": {
+			options: []ConnectorOption{WithTimeEncoding(TimeEncodingEpochMillis)},
+			written: &types.AttributeValueMemberN{Value: "1704164645123"},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+
+				input := &dynamodb.ExecuteStatementInput{
+					Statement:  aws.String(`UPDATE "sessions" SET expires_at = ? WHERE id = '1'`),
+					Parameters: []types.AttributeValue{tt.written},
+				}
+				WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil).
+					Verify(Times(1))
+				query := `SELECT expires_at FROM "sessions" WHERE id = '1'`
+				WhenDouble(
+					client.ExecuteStatement(
+						AnyContext(), ExecuteStatementInputEqual(&dynamodb.ExecuteStatementInput{Statement: aws.String(query)})(),
+					),
+				).
+					ThenReturn(
+						&dynamodb.ExecuteStatementOutput{
+							Items: []map[string]types.AttributeValue{{"expires_at": tt.written}},
+						}, nil,
+					).
+					Verify(Times(1))
+
+				db := sql.OpenDB(NewConnector(aws.Config{}, append(tt.options, WithDynamoDBClient(client))...))
+				defer db.Close()
+
+				if _, err := db.ExecContext(context.Background(), *input.Statement, expiresAt); err != nil {
+					t.Fatalf("ExecContext() unexpected error = %v", err)
+				}
+				var got time.Time
+				if err := db.QueryRowContext(context.Background(), query).Scan(&got); err != nil {
+					t.Fatalf("Scan() unexpected error = %v", err)
+				}
+				if !got.Equal(expiresAt) {
+					t.Errorf("Scan() = %v, want %v", got, expiresAt)
+				}
+			},
+		)
+	}
+}
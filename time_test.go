@@ -0,0 +1,186 @@
+package pqxd
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb"
+	"github.com/aws/aws-sdk-go-v2/service/dynamodb/types"
+	. "github.com/ovechkin-dm/mockio/v2/mock"
+)
+
+func TestTime_Scan(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	type test struct {
+		src  any
+		dest sql.Scanner
+		want time.Time
+	}
+	tests := map[string]test{
+		"epoch-time-from-float64": {
+			src:  float64(expiresAt.Unix()),
+			dest: new(EpochTime),
+			want: expiresAt,
+		},
+		"epoch-time-from-fraction": {
+			src:  json.Number("1704164645.5"),
+			dest: new(EpochTime),
+			want: expiresAt.Add(500 * time.Millisecond),
+		},
+		"epoch-millis-from-decimal": {
+			src:  must(ParseDecimal("1704164645123")),
+			dest: new(EpochMillis),
+			want: expiresAt.Add(123 * time.Millisecond),
+		},
+		"epoch-millis-from-numeric-string": {
+			src:  "1704164645000",
+			dest: new(EpochMillis),
+			want: expiresAt,
+		},
+		"rfc3339-time-from-string": {
+			src:  "2024-01-02T03:04:05Z",
+			dest: new(RFC3339Time),
+			want: expiresAt,
+		},
+		"time-from-number": {
+			src:  float64(expiresAt.Unix()),
+			dest: Time(new(time.Time), TimeEncodingRFC3339),
+			want: expiresAt,
+		},
+		"time-from-number-in-millis": {
+			src:  float64(expiresAt.UnixMilli()),
+			dest: Time(new(time.Time), TimeEncodingEpochMillis),
+			want: expiresAt,
+		},
+		"time-from-string": {
+			src:  "2024-01-02T03:04:05Z",
+			dest: Time(new(time.Time), TimeEncodingEpochMillis),
+			want: expiresAt,
+		},
+		"null": {
+			src:  nil,
+			dest: &EpochTime{Time: expiresAt},
+			want: time.Time{},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				if err := tt.dest.Scan(tt.src); err != nil {
+					t.Fatalf("Scan() unexpected error = %v", err)
+				}
+				var got time.Time
+				switch v := tt.dest.(type) {
+				case *EpochTime:
+					got = v.Time
+				case *EpochMillis:
+					got = v.Time
+				case *RFC3339Time:
+					got = v.Time
+				case *timeScanner:
+					got = *v.dst
+				}
+				if !got.Equal(tt.want) {
+					t.Errorf("Scan() = %v, want %v", got, tt.want)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_ExecContext_with_TimeEncoding(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	type test struct {
+		options []ConnectorOption
+		arg     any
+		want    types.AttributeValue
+	}
+	tests := map[string]test{
+		"default": {
+			arg:  expiresAt,
+			want: &types.AttributeValueMemberS{Value: "2024-01-02T03:04:05Z"},
+		},
+		"epoch-seconds": {
+			options: []ConnectorOption{WithTimeEncoding(TimeEncodingEpochSeconds)},
+			arg:     expiresAt,
+			want:    &types.AttributeValueMemberN{Value: "1704164645"},
+		},
+		"epoch-millis-overrides-encoding": {
+			options: []ConnectorOption{WithTimeEncoding(TimeEncodingRFC3339)},
+			arg:     EpochMillis{Time: expiresAt},
+			want:    &types.AttributeValueMemberN{Value: "1704164645000"},
+		},
+		"zero-epoch-time": {
+			arg:  EpochTime{},
+			want: &types.AttributeValueMemberNULL{Value: true},
+		},
+	}
+	for name, tt := range tests {
+		t.Run(
+			name, func(t *testing.T) {
+				ctrl := NewMockController(t)
+				client := Mock[DynamoDBClient](ctrl)
+
+				input := &dynamodb.ExecuteStatementInput{
+					Statement:  aws.String(`UPDATE "sessions" SET expires_at = ? WHERE id = '1'`),
+					Parameters: []types.AttributeValue{tt.want},
+				}
+				WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+					ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil).
+					Verify(Times(1))
+
+				db := sql.OpenDB(NewConnector(aws.Config{}, append(tt.options, WithDynamoDBClient(client))...))
+				defer db.Close()
+
+				if _, err := db.ExecContext(context.Background(), *input.Statement, tt.arg); err != nil {
+					t.Fatalf("ExecContext() unexpected error = %v", err)
+				}
+			},
+		)
+	}
+}
+
+func Test_Connection_time_roundtrip_with_TimeEncoding(t *testing.T) {
+	expiresAt := time.Date(2024, 1, 2, 3, 4, 5, 123000000, time.UTC)
+	ctrl := NewMockController(t)
+	client := Mock[DynamoDBClient](ctrl)
+
+	written := &types.AttributeValueMemberN{Value: "1704164645123"}
+	input := &dynamodb.ExecuteStatementInput{
+		Statement:  aws.String(`UPDATE "sessions" SET expires_at = ? WHERE id = '1'`),
+		Parameters: []types.AttributeValue{written},
+	}
+	WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(input)())).
+		ThenReturn(&dynamodb.ExecuteStatementOutput{}, nil).
+		Verify(Times(1))
+	WhenDouble(client.ExecuteStatement(AnyContext(), ExecuteStatementInputEqual(&dynamodb.ExecuteStatementInput{
+		Statement: aws.String(`SELECT expires_at FROM "sessions" WHERE id = '1'`),
+	})())).
+		ThenReturn(
+			&dynamodb.ExecuteStatementOutput{
+				Items: []map[string]types.AttributeValue{{"expires_at": written}},
+			}, nil,
+		).
+		Verify(Times(1))
+
+	db := sql.OpenDB(
+		NewConnector(aws.Config{}, WithDynamoDBClient(client), WithTimeEncoding(TimeEncodingEpochMillis)),
+	)
+	defer db.Close()
+
+	if _, err := db.ExecContext(context.Background(), *input.Statement, expiresAt); err != nil {
+		t.Fatalf("ExecContext() unexpected error = %v", err)
+	}
+	var got time.Time
+	row := db.QueryRowContext(context.Background(), `SELECT expires_at FROM "sessions" WHERE id = '1'`)
+	if err := row.Scan(Time(&got, TimeEncodingEpochMillis)); err != nil {
+		t.Fatalf("Scan() unexpected error = %v", err)
+	}
+	if !got.Equal(expiresAt) {
+		t.Errorf("Scan() = %v, want %v", got, expiresAt)
+	}
+}
@@ -48,11 +48,41 @@ type transactionInOut struct {
 type transactionStatementPublisher struct {
 	ch        chan *transactionInOut
 	closeOnce sync.Once
+
+	// readOnly if true, only read statements are accepted
+	readOnly bool
+
+	// kind is the kind of statements published so far. zero if nothing is published.
+	kind txStatementKind
+
+	// mu guards kind
+	mu sync.Mutex
 }
 
 // publish publishes a statement.
-func (p *transactionStatementPublisher) publish(inout *transactionInOut) {
+// DynamoDB transactions must consist of either read statements or write statements,
+// so publish rejects the statement of a kind other than those published before.
+func (p *transactionStatementPublisher) publish(inout *transactionInOut, kind txStatementKind) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case kind == 0:
+		return ErrNotSupportedWithinTx
+	case p.readOnly && kind != txStatementRead:
+		return ErrWriteInReadOnlyTx
+	case p.kind != 0 && p.kind != kind:
+		return ErrMixedTxStatements
+	}
+	p.kind = kind
 	p.ch <- inout
+	return nil
+}
+
+// publishedKind returns the kind of statements published so far. zero if nothing is published.
+func (p *transactionStatementPublisher) publishedKind() txStatementKind {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.kind
 }
 
 // close closes the channel.
@@ -69,6 +99,9 @@ type txCommit struct {
 	ctx           context.Context
 	function      context.CancelFunc
 	receiveResult context.Context
+
+	// capacity records the capacity consumed by the transaction
+	capacity *capacityRecorder
 }
 
 // txRollback represents a rollback operation in a transaction.